@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// hostSnapshot 后台采集得到的单个主机的最新结果
+type hostSnapshot struct {
+	metrics     []prometheus.Metric
+	collectedAt time.Time // 本次结果的采集完成时间
+	lastSuccess time.Time // 最近一次SSH连接成功的时间
+}
+
+// StartBackground 启动后台采集，每个主机按各自的间隔采集并缓存结果
+func (c *SSHCollector) StartBackground() {
+	c.snapshots = make([]*hostSnapshot, len(c.hosts))
+	c.background = true
+
+	for i, target := range c.hosts {
+		go c.backgroundLoop(i, target)
+	}
+	logger.Printf("Background collection started for %d hosts", len(c.hosts))
+}
+
+// backgroundLoop 单个主机的后台采集循环
+func (c *SSHCollector) backgroundLoop(index int, target hostTarget) {
+	interval := target.config.Interval
+
+	// 随机延迟首次采集，避免所有主机同时发起连接
+	time.Sleep(rand.N(interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.collectSnapshot(index, target)
+		<-ticker.C
+	}
+}
+
+// collectSnapshot 采集单个主机并替换其缓存结果
+func (c *SSHCollector) collectSnapshot(index int, target hostTarget) {
+	metricsChan := make(chan prometheus.Metric, 100)
+	successChan := make(chan bool, 1)
+	go func() {
+		successChan <- c.collectHostMetrics(context.Background(), target, metricsChan)
+		close(metricsChan)
+	}()
+
+	var metrics []prometheus.Metric
+	for metric := range metricsChan {
+		metrics = append(metrics, metric)
+	}
+	success := <-successChan
+	now := time.Now()
+
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+
+	snapshot := &hostSnapshot{
+		metrics:     metrics,
+		collectedAt: now,
+	}
+	if previous := c.snapshots[index]; previous != nil {
+		snapshot.lastSuccess = previous.lastSuccess
+	}
+	if success {
+		snapshot.lastSuccess = now
+	}
+	c.snapshots[index] = snapshot
+}
+
+// collectFromSnapshots 输出缓存的采集结果，超过max_age的结果被丢弃
+func (c *SSHCollector) collectFromSnapshots(ch chan<- prometheus.Metric) {
+	c.snapshotMu.RLock()
+	defer c.snapshotMu.RUnlock()
+
+	now := time.Now()
+	maxAge := c.config.Background.MaxAge
+
+	for i, snapshot := range c.snapshots {
+		if snapshot == nil {
+			// 尚未完成首次采集
+			continue
+		}
+		host := c.hosts[i].config.Host
+		age := now.Sub(snapshot.collectedAt)
+
+		ch <- prometheus.MustNewConstMetric(
+			c.hostSnapshotAge,
+			prometheus.GaugeValue,
+			age.Seconds(),
+			host,
+		)
+		if !snapshot.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				c.hostLastSuccess,
+				prometheus.GaugeValue,
+				float64(snapshot.lastSuccess.Unix()),
+				host,
+			)
+		}
+
+		if maxAge > 0 && age > maxAge {
+			continue
+		}
+		for _, metric := range snapshot.metrics {
+			ch <- metric
+		}
+	}
+}
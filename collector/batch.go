@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ssh_exporter/config"
+	"ssh_exporter/transport"
+)
+
+// CommandLister 可选接口，监控器返回本次采集将要执行的命令，
+// 采集器会把同一主机所有监控器的命令合并到一个会话中执行，监控器执行这些命令时直接得到缓存的结果
+type CommandLister interface {
+	// Commands 返回使用spec采集时执行的命令，同一命令执行多次时应重复列出
+	Commands(spec any) []string
+}
+
+// batchExecutor 缓存批量执行结果的执行器
+// 每个结果只使用一次，未缓存（或已用完）的命令交给底层执行器单独执行
+type batchExecutor struct {
+	transport.Executor
+
+	mu      sync.Mutex
+	results map[string][]transport.Result
+}
+
+// ExecuteCommand 实现transport.Executor接口
+func (e *batchExecutor) ExecuteCommand(ctx context.Context, command string) (transport.Result, error) {
+	e.mu.Lock()
+	if results := e.results[command]; len(results) > 0 {
+		e.results[command] = results[1:]
+		e.mu.Unlock()
+		return results[0], nil
+	}
+	e.mu.Unlock()
+	return e.Executor.ExecuteCommand(ctx, command)
+}
+
+// batchCommands 返回主机启用的监控器声明的全部命令
+func batchCommands(monitors []boundMonitor) []string {
+	var commands []string
+	for _, bm := range monitors {
+		if lister, ok := bm.monitor.(CommandLister); ok {
+			commands = append(commands, lister.Commands(bm.spec)...)
+		}
+	}
+	return commands
+}
+
+// batchTimeout 返回批量执行的时间限制，即提供命令的监控器中最长的超时时间
+// monitor_timeouts允许某个监控器执行更久时，批量执行不会在command_timeout时就被终止
+func batchTimeout(hc config.HostConfig, monitors []boundMonitor) time.Duration {
+	timeout := time.Duration(0)
+	for _, bm := range monitors {
+		if _, ok := bm.monitor.(CommandLister); !ok {
+			continue
+		}
+		timeout = max(timeout, monitorTimeout(hc, bm.name))
+	}
+	return timeout
+}
+
+// newBatchExecutor 在一个会话中执行全部命令并缓存每个命令的结果
+// 每个命令在子shell中执行，输出前后带有分隔行；脚本失败或被中断时，
+// 只有完整输出的部分会被缓存，其余命令之后单独执行
+func newBatchExecutor(ctx context.Context, exec transport.Executor, host string, commands []string) *batchExecutor {
+	batch := &batchExecutor{Executor: exec, results: make(map[string][]transport.Result)}
+
+	marker, err := batchMarker()
+	if err != nil {
+		logger.Printf("Not batching commands for %s: %v", host, err)
+		return batch
+	}
+
+	result, err := exec.ExecuteCommand(ctx, batchScript(marker, commands))
+	if err != nil {
+		logger.Printf("Batched commands on %s failed: %v", host, err)
+	}
+
+	cached := 0
+	for i, command := range commands {
+		section, ok := batchSection(marker, i, result)
+		if !ok {
+			continue
+		}
+		section.Duration = result.Duration
+		batch.results[command] = append(batch.results[command], section)
+		cached++
+	}
+	if cached < len(commands) {
+		logger.Printf("Batch on %s returned %d of %d sections, running the rest separately", host, cached, len(commands))
+	}
+	return batch
+}
+
+// batchMarker 生成分隔行使用的随机标记，避免与命令输出冲突
+func batchMarker() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate batch marker: %w", err)
+	}
+	return "ssh_exporter_batch_" + hex.EncodeToString(b), nil
+}
+
+// batchScript 生成批量执行的脚本
+// stdout中每个命令的输出为 "<marker> begin <i>\n" + 输出 + "\n<marker> end <i> <退出码>\n"，
+// stderr中相同，只是结束行不带退出码
+func batchScript(marker string, commands []string) string {
+	var b strings.Builder
+	for i, command := range commands {
+		fmt.Fprintf(&b, "printf '%%s\\n' '%s begin %d'; printf '%%s\\n' '%s begin %d' >&2\n", marker, i, marker, i)
+		// 命令后换行，避免命令末尾的注释吞掉右括号
+		fmt.Fprintf(&b, "(\n%s\n)\n", command)
+		fmt.Fprintf(&b, "printf '\\n%%s\\n' \"%s end %d $?\"; printf '\\n%%s\\n' '%s end %d' >&2\n", marker, i, marker, i)
+	}
+	return b.String()
+}
+
+// batchSection 从批量执行的结果中取出第i个命令的结果，输出不完整时返回false
+func batchSection(marker string, i int, result transport.Result) (transport.Result, bool) {
+	stdout, status, ok := cutSection(result.Stdout, marker, i)
+	if !ok {
+		return transport.Result{}, false
+	}
+	stderr, _, ok := cutSection(result.Stderr, marker, i)
+	if !ok {
+		return transport.Result{}, false
+	}
+	exitCode, err := strconv.Atoi(status)
+	if err != nil {
+		return transport.Result{}, false
+	}
+	return transport.Result{Stdout: stdout, Stderr: stderr, ExitCode: exitCode}, true
+}
+
+// cutSection 返回output中第i部分的内容以及结束行中标记之后的内容
+func cutSection(output, marker string, i int) (string, string, bool) {
+	begin := fmt.Sprintf("%s begin %d\n", marker, i)
+	end := fmt.Sprintf("\n%s end %d", marker, i)
+
+	start := strings.Index(output, begin)
+	if start < 0 {
+		return "", "", false
+	}
+	output = output[start+len(begin):]
+	content, rest, ok := strings.Cut(output, end)
+	if !ok {
+		return "", "", false
+	}
+	status, _, ok := strings.Cut(rest, "\n")
+	if !ok {
+		return "", "", false
+	}
+	return content, strings.TrimSpace(status), true
+}
@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ssh_exporter/transport"
+)
+
+const testMarker = "ssh_exporter_batch_0123456789abcdef"
+
+func TestBatchScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    transport.Result
+	}{
+		{name: "output", command: "echo hello", want: transport.Result{Stdout: "hello\n"}},
+		{name: "no trailing newline", command: "printf abc", want: transport.Result{Stdout: "abc"}},
+		{name: "no output", command: "true", want: transport.Result{}},
+		{name: "trailing comment", command: "echo commented # ) comment", want: transport.Result{Stdout: "commented\n"}},
+		{
+			name:    "stderr and exit status",
+			command: "echo out; echo err >&2; exit 3",
+			want:    transport.Result{Stdout: "out\n", Stderr: "err\n", ExitCode: 3},
+		},
+		{name: "blank lines kept", command: "printf '\\n\\nx\\n\\n'", want: transport.Result{Stdout: "\n\nx\n\n"}},
+	}
+
+	// 所有命令在同一个脚本中执行，每个命令的结果互不影响
+	commands := make([]string, len(tests))
+	for i, tt := range tests {
+		commands[i] = tt.command
+	}
+	result, err := transport.NewLocalExecutor().ExecuteCommand(context.Background(), batchScript(testMarker, commands))
+	if err != nil {
+		t.Fatalf("batch script failed: %v", err)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := batchSection(testMarker, i, result)
+			if !ok {
+				t.Fatalf("batchSection(%d) found no complete section in %q", i, result.Stdout)
+			}
+			if got != tt.want {
+				t.Errorf("batchSection(%d) = %+v, want %+v", i, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutSection(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		i           int
+		wantContent string
+		wantStatus  string
+		wantOK      bool
+	}{
+		{
+			name:        "complete",
+			output:      testMarker + " begin 0\nhello\n\n" + testMarker + " end 0 0\n",
+			wantContent: "hello\n",
+			wantStatus:  "0",
+			wantOK:      true,
+		},
+		{
+			name:        "no trailing newline",
+			output:      testMarker + " begin 0\nabc\n" + testMarker + " end 0 1\n",
+			wantContent: "abc",
+			wantStatus:  "1",
+			wantOK:      true,
+		},
+		{
+			name:        "stderr end line",
+			output:      testMarker + " begin 0\nerr\n\n" + testMarker + " end 0\n",
+			wantContent: "err\n",
+			wantOK:      true,
+		},
+		{
+			name: "second section",
+			output: testMarker + " begin 0\na\n\n" + testMarker + " end 0 0\n" +
+				testMarker + " begin 1\nb\n\n" + testMarker + " end 1 2\n",
+			i:           1,
+			wantContent: "b\n",
+			wantStatus:  "2",
+			wantOK:      true,
+		},
+		{
+			name:   "section 1 does not match section 10",
+			output: testMarker + " begin 10\nx\n\n" + testMarker + " end 10 0\n",
+			i:      1,
+		},
+		{
+			name:   "interrupted before end line",
+			output: testMarker + " begin 0\npartial output",
+		},
+		{
+			name:   "interrupted within end line",
+			output: testMarker + " begin 0\nhello\n\n" + testMarker + " end 0 0",
+		},
+		{
+			name:   "missing section",
+			output: testMarker + " begin 0\nhello\n\n" + testMarker + " end 0 0\n",
+			i:      1,
+		},
+		{name: "empty output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, status, ok := cutSection(tt.output, testMarker, tt.i)
+			if ok != tt.wantOK || content != tt.wantContent || status != tt.wantStatus {
+				t.Errorf("cutSection(%d) = %q, %q, %v, want %q, %q, %v",
+					tt.i, content, status, ok, tt.wantContent, tt.wantStatus, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBatchSectionIncomplete(t *testing.T) {
+	complete := testMarker + " begin 0\nout\n\n" + testMarker + " end 0 0\n"
+	tests := []struct {
+		name   string
+		result transport.Result
+	}{
+		{
+			name:   "stderr interrupted",
+			result: transport.Result{Stdout: complete, Stderr: testMarker + " begin 0\n"},
+		},
+		{
+			name: "exit status not a number",
+			result: transport.Result{
+				Stdout: testMarker + " begin 0\nout\n\n" + testMarker + " end 0 x\n",
+				Stderr: testMarker + " begin 0\n\n" + testMarker + " end 0\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := batchSection(testMarker, 0, tt.result); ok {
+				t.Errorf("batchSection() = %+v, want no section", got)
+			}
+		})
+	}
+}
+
+func TestNewBatchExecutorInterrupted(t *testing.T) {
+	// 第二个命令终止整个脚本，之后的命令没有被批量执行，需要单独执行
+	commands := []string{"echo first", "kill -KILL $$", "echo after"}
+	batch := newBatchExecutor(context.Background(), transport.NewLocalExecutor(), "local", commands)
+
+	if got := len(batch.results["echo first"]); got != 1 {
+		t.Fatalf("cached %d results for the command before the interruption, want 1", got)
+	}
+	if got := len(batch.results["echo after"]); got != 0 {
+		t.Fatalf("cached %d results for the command after the interruption, want 0", got)
+	}
+
+	for _, command := range []string{"echo first", "echo after"} {
+		result, err := batch.ExecuteCommand(context.Background(), command)
+		if err != nil {
+			t.Fatalf("ExecuteCommand(%q) error = %v", command, err)
+		}
+		if want := strings.TrimPrefix(command, "echo ") + "\n"; result.Stdout != want {
+			t.Errorf("ExecuteCommand(%q) stdout = %q, want %q", command, result.Stdout, want)
+		}
+	}
+}
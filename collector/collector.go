@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -8,6 +10,7 @@ import (
 
 	"ssh_exporter/config"
 	sshclient "ssh_exporter/ssh"
+	"ssh_exporter/transport"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -17,76 +20,130 @@ var logger = log.New(os.Stdout, "[Collector] ", log.LstdFlags)
 // SSHCollector 实现Prometheus Collector接口
 type SSHCollector struct {
 	config       *config.Config
+	pool         *sshclient.Pool    // SSH连接池，在多次抓取之间复用连接
+	sshMetrics   *sshclient.Metrics // SSH连接指标
 	mu           sync.Mutex
 	metricPrefix string // 指标名称前缀
 
-	// 进程监控指标
-	processPatternCount *prometheus.Desc
+	// 各主机、各probe模块启用的监控器
+	hosts   []hostTarget
+	modules map[string][]boundMonitor
 
-	// 文件监控指标
-	fileSize         *prometheus.Desc
-	fileLastModified *prometheus.Desc
-	fileAgeMinutes   *prometheus.Desc
+	// 配置中的主机和跳板机，/metrics 只输出它们的错误计数和握手耗时
+	configuredHosts map[string]bool
+	// 探测过的目标及最近一次探测时间
+	probeMu      sync.Mutex
+	probeTargets map[string]time.Time
+
+	// 各主机在一次连接期间不变的信息
+	facts factsCache
+
+	// 后台采集模式下缓存的各主机结果，与hosts一一对应
+	background bool
+	snapshotMu sync.RWMutex
+	snapshots  []*hostSnapshot
 
 	// 主机状态指标
 	hostSSHStatus *prometheus.Desc
+	hostSSHError  *prometheus.Desc
 	hostLastCheck *prometheus.Desc
+	hostSSHAuth   *prometheus.Desc
+
+	// 采集健康指标
+	scrapeDuration  *prometheus.Desc
+	scrapeSuccess   *prometheus.Desc
+	commandExitCode *prometheus.Desc
+	commandErrors   *prometheus.CounterVec
+
+	// 后台采集指标
+	hostSnapshotAge *prometheus.Desc
+	hostLastSuccess *prometheus.Desc
 
-	// CPU指标
-	cpuUserSeconds   *prometheus.Desc
-	cpuSystemSeconds *prometheus.Desc
-	cpuIdleSeconds   *prometheus.Desc
-	cpuIowaitSeconds *prometheus.Desc
-	cpuUsagePercent  *prometheus.Desc
-	contextSwitches  *prometheus.Desc
-	interrupts       *prometheus.Desc
-	processesRunning *prometheus.Desc
-	processesBlocked *prometheus.Desc
-
-	// 内存指标
-	memoryTotalBytes     *prometheus.Desc
-	memoryFreeBytes      *prometheus.Desc
-	memoryAvailableBytes *prometheus.Desc
-	memoryBuffersBytes   *prometheus.Desc
-	memoryCachedBytes    *prometheus.Desc
-	memoryUsagePercent   *prometheus.Desc
-
-	// 磁盘指标
-	diskTotalBytes   *prometheus.Desc
-	diskUsedBytes    *prometheus.Desc
-	diskFreeBytes    *prometheus.Desc
-	diskUsagePercent *prometheus.Desc
+	// 探测指标
+	probeSuccess  *prometheus.Desc
+	probeDuration *prometheus.Desc
 }
 
-// NewSSHCollector 创建新的SSH Collector
-func NewSSHCollector(cfg *config.Config) *SSHCollector {
+// hostTarget 待采集的主机及其启用的监控器
+type hostTarget struct {
+	config   config.HostConfig
+	monitors []boundMonitor
+}
+
+// NewSSHCollector 创建新的SSH Collector，并解析所有主机和probe模块的监控配置
+func NewSSHCollector(cfg *config.Config) (*SSHCollector, error) {
 	prefix := cfg.MetricPrefix
+	monitors := newMonitors(prefix)
+
+	hosts := make([]hostTarget, 0, len(cfg.Hosts))
+	configuredHosts := make(map[string]bool)
+	for _, hc := range cfg.Hosts {
+		configuredHosts[hc.Host] = true
+		for _, jump := range hc.JumpHosts {
+			configuredHosts[jump.Host] = true
+		}
+		bound, err := decodeMonitors(monitors, hc.Monitors)
+		if err != nil {
+			return nil, fmt.Errorf("host %s: %w", hc.Host, err)
+		}
+		if err := checkMonitorTimeouts(monitors, hc); err != nil {
+			return nil, fmt.Errorf("host %s: %w", hc.Host, err)
+		}
+		hosts = append(hosts, hostTarget{config: hc, monitors: bound})
+	}
+	modules := make(map[string][]boundMonitor, len(cfg.Modules))
+	for name, hc := range cfg.Modules {
+		for _, jump := range hc.JumpHosts {
+			configuredHosts[jump.Host] = true
+		}
+		bound, err := decodeMonitors(monitors, hc.Monitors)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", name, err)
+		}
+		if err := checkMonitorTimeouts(monitors, hc); err != nil {
+			return nil, fmt.Errorf("module %s: %w", name, err)
+		}
+		modules[name] = bound
+	}
+
+	sshMetrics := sshclient.NewMetrics(prefix)
 	return &SSHCollector{
-		config:       cfg,
+		config:  cfg,
+		hosts:   hosts,
+		modules: modules,
+
+		configuredHosts: configuredHosts,
+		pool: sshclient.NewPool(sshclient.PoolOptions{
+			KeepaliveInterval: cfg.SSHPool.KeepaliveInterval,
+			IdleTimeout:       cfg.SSHPool.IdleTimeout,
+			Metrics:           sshMetrics,
+		}),
+		sshMetrics:   sshMetrics,
 		metricPrefix: prefix,
-		processPatternCount: prometheus.NewDesc(
-			prefix+"process_pattern_count",
-			"Count of pattern in process cmdlines",
-			[]string{"host", "pattern"},
+		scrapeDuration: prometheus.NewDesc(
+			prefix+"scrape_duration_seconds",
+			"Duration of collecting a monitor on host in seconds",
+			[]string{"host", "monitor"},
 			nil,
 		),
-		fileSize: prometheus.NewDesc(
-			prefix+"file_size_bytes",
-			"File size in bytes",
-			[]string{"host", "path", "filename"},
+		scrapeSuccess: prometheus.NewDesc(
+			prefix+"scrape_success",
+			"Whether collecting a monitor on host succeeded (1: success, 0: failure)",
+			[]string{"host", "monitor"},
 			nil,
 		),
-		fileLastModified: prometheus.NewDesc(
-			prefix+"file_last_modified_timestamp",
-			"Last modified timestamp of file",
-			[]string{"host", "path", "filename"},
+		commandExitCode: prometheus.NewDesc(
+			prefix+"command_exit_code",
+			"Exit code of the first failed command of a monitor on host (-1: killed by a signal, 0: no command failed with an exit code)",
+			[]string{"host", "monitor"},
 			nil,
 		),
-		fileAgeMinutes: prometheus.NewDesc(
-			prefix+"file_age_minutes",
-			"Minutes since last modification",
-			[]string{"host", "path", "filename"},
-			nil,
+		commandErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: prefix + "command_errors_total",
+				Help: "Total number of failed monitor commands by reason",
+			},
+			[]string{"host", "monitor", "reason"},
 		),
 		hostSSHStatus: prometheus.NewDesc(
 			prefix+"host_ssh_status",
@@ -94,160 +151,131 @@ func NewSSHCollector(cfg *config.Config) *SSHCollector {
 			[]string{"host"},
 			nil,
 		),
+		hostSSHError: prometheus.NewDesc(
+			prefix+"host_ssh_error",
+			"SSH connection failure reason of host (1 for the reason of the last failure)",
+			[]string{"host", "reason"},
+			nil,
+		),
 		hostLastCheck: prometheus.NewDesc(
 			prefix+"host_last_check_timestamp",
 			"Last successful check timestamp of host",
 			[]string{"host"},
 			nil,
 		),
-		cpuUserSeconds: prometheus.NewDesc(
-			prefix+"cpu_user_seconds_total",
-			"Total CPU time spent in user mode",
-			[]string{"host"},
-			nil,
-		),
-		cpuSystemSeconds: prometheus.NewDesc(
-			prefix+"cpu_system_seconds_total",
-			"Total CPU time spent in system mode",
-			[]string{"host"},
-			nil,
-		),
-		cpuIdleSeconds: prometheus.NewDesc(
-			prefix+"cpu_idle_seconds_total",
-			"Total CPU idle time",
-			[]string{"host"},
-			nil,
-		),
-		cpuIowaitSeconds: prometheus.NewDesc(
-			prefix+"cpu_iowait_seconds_total",
-			"Total CPU time waiting for I/O",
-			[]string{"host"},
-			nil,
-		),
-		cpuUsagePercent: prometheus.NewDesc(
-			prefix+"cpu_usage_percent",
-			"CPU usage percentage",
-			[]string{"host"},
-			nil,
-		),
-		contextSwitches: prometheus.NewDesc(
-			prefix+"context_switches_total",
-			"Total number of context switches",
-			[]string{"host"},
+		hostSSHAuth: prometheus.NewDesc(
+			prefix+"host_ssh_auth_info",
+			"SSH authentication method that succeeded for the current connection to host",
+			[]string{"host", "method"},
 			nil,
 		),
-		interrupts: prometheus.NewDesc(
-			prefix+"interrupts_total",
-			"Total number of interrupts",
+		hostSnapshotAge: prometheus.NewDesc(
+			prefix+"host_snapshot_age_seconds",
+			"Seconds since the cached background result of host was collected",
 			[]string{"host"},
 			nil,
 		),
-		processesRunning: prometheus.NewDesc(
-			prefix+"processes_running",
-			"Number of processes in running state",
+		hostLastSuccess: prometheus.NewDesc(
+			prefix+"host_last_success_timestamp",
+			"Timestamp of the last successful background collection of host",
 			[]string{"host"},
 			nil,
 		),
-		processesBlocked: prometheus.NewDesc(
-			prefix+"processes_blocked",
-			"Number of processes blocked waiting for I/O",
-			[]string{"host"},
-			nil,
-		),
-		memoryTotalBytes: prometheus.NewDesc(
-			prefix+"memory_total_bytes",
-			"Total memory in bytes",
-			[]string{"host"},
+		probeSuccess: prometheus.NewDesc(
+			prefix+"probe_success",
+			"Whether the SSH probe succeeded (1: success, 0: failure)",
 			nil,
-		),
-		memoryFreeBytes: prometheus.NewDesc(
-			prefix+"memory_free_bytes",
-			"Free memory in bytes",
-			[]string{"host"},
 			nil,
 		),
-		memoryAvailableBytes: prometheus.NewDesc(
-			prefix+"memory_available_bytes",
-			"Available memory in bytes",
-			[]string{"host"},
+		probeDuration: prometheus.NewDesc(
+			prefix+"probe_duration_seconds",
+			"Duration of the SSH probe in seconds",
 			nil,
-		),
-		memoryBuffersBytes: prometheus.NewDesc(
-			prefix+"memory_buffers_bytes",
-			"Memory used for buffers in bytes",
-			[]string{"host"},
-			nil,
-		),
-		memoryCachedBytes: prometheus.NewDesc(
-			prefix+"memory_cached_bytes",
-			"Memory used for cache in bytes",
-			[]string{"host"},
-			nil,
-		),
-		memoryUsagePercent: prometheus.NewDesc(
-			prefix+"memory_usage_percent",
-			"Memory usage percentage",
-			[]string{"host"},
-			nil,
-		),
-		diskTotalBytes: prometheus.NewDesc(
-			prefix+"disk_total_bytes",
-			"Total disk space in bytes",
-			[]string{"host", "device", "mount_point"},
-			nil,
-		),
-		diskUsedBytes: prometheus.NewDesc(
-			prefix+"disk_used_bytes",
-			"Used disk space in bytes",
-			[]string{"host", "device", "mount_point"},
-			nil,
-		),
-		diskFreeBytes: prometheus.NewDesc(
-			prefix+"disk_free_bytes",
-			"Free disk space in bytes",
-			[]string{"host", "device", "mount_point"},
-			nil,
-		),
-		diskUsagePercent: prometheus.NewDesc(
-			prefix+"disk_usage_percent",
-			"Disk usage percentage",
-			[]string{"host", "device", "mount_point"},
 			nil,
 		),
+	}, nil
+}
+
+// checkMonitorTimeouts 检查monitor_timeouts中的监控器名称
+func checkMonitorTimeouts(monitors map[string]Monitor, hc config.HostConfig) error {
+	for name := range hc.MonitorTimeouts {
+		if _, ok := monitors[name]; !ok {
+			return fmt.Errorf("monitor_timeouts: unknown monitor %q", name)
+		}
 	}
+	return nil
 }
 
 // Describe 实现Prometheus Collector接口
 func (c *SSHCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.processPatternCount
-	ch <- c.fileSize
-	ch <- c.fileLastModified
-	ch <- c.fileAgeMinutes
 	ch <- c.hostSSHStatus
+	ch <- c.hostSSHError
 	ch <- c.hostLastCheck
-	ch <- c.cpuUserSeconds
-	ch <- c.cpuSystemSeconds
-	ch <- c.cpuIdleSeconds
-	ch <- c.cpuIowaitSeconds
-	ch <- c.cpuUsagePercent
-	ch <- c.contextSwitches
-	ch <- c.interrupts
-	ch <- c.processesRunning
-	ch <- c.processesBlocked
-	ch <- c.memoryTotalBytes
-	ch <- c.memoryFreeBytes
-	ch <- c.memoryAvailableBytes
-	ch <- c.memoryBuffersBytes
-	ch <- c.memoryCachedBytes
-	ch <- c.memoryUsagePercent
-	ch <- c.diskTotalBytes
-	ch <- c.diskUsedBytes
-	ch <- c.diskFreeBytes
-	ch <- c.diskUsagePercent
+	ch <- c.hostSSHAuth
+	ch <- c.hostSnapshotAge
+	ch <- c.hostLastSuccess
+	ch <- c.scrapeDuration
+	ch <- c.scrapeSuccess
+	ch <- c.commandExitCode
+	c.commandErrors.Describe(ch)
+	c.sshMetrics.Describe(ch)
+	if len(c.modules) > 0 {
+		ch <- c.probeSuccess
+		ch <- c.probeDuration
+	}
+
+	// 只描述主机或probe模块启用的监控器，未启用的监控器不参与注册时的重名检查
+	described := make(map[string]bool)
+	describe := func(monitors []boundMonitor) {
+		for _, bm := range monitors {
+			if !described[bm.name] {
+				described[bm.name] = true
+				bm.monitor.Describe(ch)
+			}
+		}
+	}
+	for _, target := range c.hosts {
+		describe(target.monitors)
+	}
+	for _, monitors := range c.modules {
+		describe(monitors)
+	}
 }
 
 // Collect 实现Prometheus Collector接口
 func (c *SSHCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(context.Background(), ch)
+}
+
+// WithContext 返回在ctx下采集的Collector，ctx被取消（例如HTTP请求结束）时终止正在执行的命令
+func (c *SSHCollector) WithContext(ctx context.Context) prometheus.Collector {
+	return &contextCollector{parent: c, ctx: ctx}
+}
+
+// contextCollector 使用指定context采集的SSHCollector
+type contextCollector struct {
+	parent *SSHCollector
+	ctx    context.Context
+}
+
+// Describe 实现Prometheus Collector接口
+// 指标描述在启动时注册SSHCollector时已经检查过，这里不输出描述，每次请求注册时不会因重名失败
+func (c *contextCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect 实现Prometheus Collector接口
+func (c *contextCollector) Collect(ch chan<- prometheus.Metric) {
+	c.parent.collect(c.ctx, ch)
+}
+
+// collect 采集所有主机的指标
+func (c *SSHCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) {
+	// 后台采集模式直接返回缓存结果，无需等待SSH命令
+	if c.background {
+		c.collectFromSnapshots(ch)
+		c.collectHealth(c.configuredHosts, ch)
+		return
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -255,12 +283,12 @@ func (c *SSHCollector) Collect(ch chan<- prometheus.Metric) {
 	metricsChan := make(chan prometheus.Metric, 100)
 
 	// 为每个主机启动一个goroutine
-	for _, hostConfig := range c.config.Hosts {
+	for _, target := range c.hosts {
 		wg.Add(1)
-		go func(hc config.HostConfig) {
+		go func(t hostTarget) {
 			defer wg.Done()
-			c.collectHostMetrics(hc, metricsChan)
-		}(hostConfig)
+			c.collectHostMetrics(ctx, t, metricsChan)
+		}(target)
 	}
 
 	// 等待所有goroutine完成并关闭channel
@@ -273,46 +301,24 @@ func (c *SSHCollector) Collect(ch chan<- prometheus.Metric) {
 	for metric := range metricsChan {
 		ch <- metric
 	}
+	c.collectHealth(c.configuredHosts, ch)
 }
 
-// collectHostMetrics 收集单个主机的指标
-func (c *SSHCollector) collectHostMetrics(hostConfig config.HostConfig, ch chan<- prometheus.Metric) {
+// collectHostMetrics 收集单个主机的指标，返回SSH连接是否成功
+// 每个监控器在主机配置的超时时间内执行，ctx被取消时正在执行的命令会被终止
+func (c *SSHCollector) collectHostMetrics(ctx context.Context, target hostTarget, ch chan<- prometheus.Metric) bool {
+	hostConfig := target.config
 	logger.Printf("Collecting metrics for host: %s", hostConfig.Host)
 	currentTime := float64(time.Now().Unix())
 
-	// 创建SSH客户端
-	client, err := sshclient.NewClient(
-		hostConfig.Host,
-		hostConfig.User,
-		hostConfig.Password,
-		hostConfig.PrivateKeyPath,
-		hostConfig.Port,
-	)
-	if err != nil {
-		logger.Printf("Failed to create SSH client for %s: %v", hostConfig.Host, err)
-		// 报告连接失败
-		ch <- prometheus.MustNewConstMetric(
-			c.hostSSHStatus,
-			prometheus.GaugeValue,
-			0,
-			hostConfig.Host,
-		)
-		return
-	}
-
-	err = client.Connect()
+	// 获取命令执行器（SSH客户端从连接池获取，连接在多次抓取之间复用）
+	exec, err := c.executor(ctx, hostConfig)
 	if err != nil {
 		logger.Printf("Failed to connect to %s: %v", hostConfig.Host, err)
 		// 报告连接失败
-		ch <- prometheus.MustNewConstMetric(
-			c.hostSSHStatus,
-			prometheus.GaugeValue,
-			0,
-			hostConfig.Host,
-		)
-		return
+		c.reportSSHFailure(hostConfig.Host, sshclient.FailureReason(err), ch)
+		return false
 	}
-	defer client.Close()
 
 	// 报告连接成功
 	ch <- prometheus.MustNewConstMetric(
@@ -327,19 +333,124 @@ func (c *SSHCollector) collectHostMetrics(hostConfig config.HostConfig, ch chan<
 		currentTime,
 		hostConfig.Host,
 	)
+	if client, ok := exec.(*sshclient.Client); ok {
+		if method := client.AuthMethod(); method != "" {
+			ch <- prometheus.MustNewConstMetric(
+				c.hostSSHAuth,
+				prometheus.GaugeValue,
+				1,
+				hostConfig.Host, method,
+			)
+		}
+	}
+
+	// 主机信息（例如USER_HZ）每个连接只读取一次，通过ctx传给监控器
+	factsCtx, cancel := context.WithTimeout(ctx, hostConfig.CommandTimeout)
+	ctx = withHostFacts(ctx, c.facts.get(factsCtx, hostConfig.Host, exec))
+	cancel()
+
+	// 监控器声明的命令合并到一个会话中执行，监控器执行时直接使用缓存的结果
+	if hostConfig.BatchCommands == nil || *hostConfig.BatchCommands {
+		if commands := batchCommands(target.monitors); len(commands) > 1 {
+			batchCtx, cancel := context.WithTimeout(ctx, batchTimeout(hostConfig, target.monitors))
+			exec = newBatchExecutor(batchCtx, exec, hostConfig.Host, commands)
+			cancel()
+		}
+	}
+
+	// 依次执行该主机启用的监控器
+	for _, bm := range target.monitors {
+		if ctx.Err() != nil {
+			logger.Printf("Collection of %s canceled: %v", hostConfig.Host, ctx.Err())
+			break
+		}
+		c.runMonitor(ctx, hostConfig.Host, bm.name, monitorTimeout(hostConfig, bm.name), ch, func(ctx context.Context) error {
+			return bm.monitor.Collect(ctx, exec, hostConfig.Host, bm.spec, ch)
+		})
+	}
 
-	// 收集进程监控指标
-	for _, processMonitor := range hostConfig.Monitors.Processes {
-		c.collectProcessMetrics(client, hostConfig.Host, processMonitor, ch)
+	return true
+}
+
+// monitorTimeout 返回主机上监控器的超时时间，monitor_timeouts优先于command_timeout
+func monitorTimeout(hc config.HostConfig, monitor string) time.Duration {
+	if timeout, ok := hc.MonitorTimeouts[monitor]; ok {
+		return timeout
 	}
+	return hc.CommandTimeout
+}
 
-	// 收集文件监控指标
-	for _, fileMonitor := range hostConfig.Monitors.Files {
-		c.collectFileMetrics(client, hostConfig.Host, fileMonitor, ch, currentTime)
+// executor 根据主机的transport配置返回命令执行器，建立SSH连接时ctx结束则不再等待
+func (c *SSHCollector) executor(ctx context.Context, hc config.HostConfig) (transport.Executor, error) {
+	switch hc.Transport {
+	case transport.Local:
+		return transport.NewLocalExecutor(), nil
+	case transport.Docker:
+		return transport.NewDockerExecutor("", hc.Container), nil
+	case transport.Nsenter:
+		return transport.NewNsenterExecutor(hc.TargetPID), nil
+	default:
+		return c.pool.Get(ctx, sshOptions(hc))
 	}
+}
+
+// reportSSHFailure 报告SSH连接失败及其原因
+func (c *SSHCollector) reportSSHFailure(host, reason string, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(
+		c.hostSSHStatus,
+		prometheus.GaugeValue,
+		0,
+		host,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.hostSSHError,
+		prometheus.GaugeValue,
+		1,
+		host, reason,
+	)
+}
+
+// sshOptions 将主机配置转换为SSH客户端参数
+func sshOptions(hc config.HostConfig) sshclient.Options {
+	opts := sshConnOptions(hc.SSHConfig)
+	for _, jump := range hc.JumpHosts {
+		opts.JumpHosts = append(opts.JumpHosts, sshConnOptions(jump))
+	}
+	return opts
+}
+
+// sshConnOptions 将SSH连接配置转换为SSH客户端参数
+func sshConnOptions(hc config.SSHConfig) sshclient.Options {
+	return sshclient.Options{
+		Host:                hc.Host,
+		HostName:            hc.HostName,
+		Port:                hc.Port,
+		User:                hc.User,
+		Password:            hc.Password,
+		PrivateKeyPath:      hc.PrivateKeyPath,
+		Certificate:         hc.Certificate,
+		Passphrase:          hc.PrivateKeyPassphrase,
+		PassphraseFile:      hc.PrivateKeyPassphraseFile,
+		UseAgent:            hc.UseAgent || hc.AgentSocket != "",
+		AgentSocket:         hc.AgentSocket,
+		AuthMethods:         hc.AuthMethods,
+		Proxy:               hc.Proxy,
+		KeyboardInteractive: keyboardInteractiveAnswers(hc.KeyboardInteractive),
+		HostKey: sshclient.HostKeyOptions{
+			Mode:           hc.HostKeyChecking,
+			KnownHostsFile: hc.KnownHostsFile,
+			Fingerprints:   hc.HostKeyFingerprints,
+			CAFile:         hc.HostCAFile,
+			HostKeyAlias:   hc.HostKeyAlias,
+		},
+	}
+}
 
-	// 收集系统统计指标
-	if hostConfig.Monitors.Stat {
-		c.collectStatMetrics(client, hostConfig.Host, ch, currentTime)
+// keyboardInteractiveAnswers 转换keyboard-interactive预设回答
+func keyboardInteractiveAnswers(answers []config.KeyboardInteractiveAnswer) []sshclient.KeyboardInteractiveAnswer {
+	var result []sshclient.KeyboardInteractiveAnswer
+	for _, a := range answers {
+		result = append(result, sshclient.KeyboardInteractiveAnswer{Prompt: a.Prompt, Answer: a.Answer})
 	}
+	return result
 }
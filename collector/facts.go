@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sshclient "ssh_exporter/ssh"
+	"ssh_exporter/transport"
+)
+
+// defaultClockTicks 无法读取CLK_TCK时使用的USER_HZ（Linux上几乎总是100）
+const defaultClockTicks = 100.0
+
+// HostFacts 主机在一次连接期间不变的信息，每个连接只读取一次
+type HostFacts struct {
+	ClockTicks float64 // USER_HZ，/proc 中以jiffies为单位的值除以它得到秒
+}
+
+type hostFactsKey struct{}
+
+// FactsFromContext 返回采集时ctx中携带的主机信息，没有时返回默认值
+func FactsFromContext(ctx context.Context) HostFacts {
+	if facts, ok := ctx.Value(hostFactsKey{}).(HostFacts); ok {
+		return facts
+	}
+	return HostFacts{ClockTicks: defaultClockTicks}
+}
+
+// withHostFacts 返回携带主机信息的ctx
+func withHostFacts(ctx context.Context, facts HostFacts) context.Context {
+	return context.WithValue(ctx, hostFactsKey{}, facts)
+}
+
+// hostFactsMaxAge 超过该时间未使用的主机信息从缓存中删除
+const hostFactsMaxAge = 10 * time.Minute
+
+// factsEntry 缓存的主机信息
+type factsEntry struct {
+	connID   uint64 // 读取时SSH连接的序号，其他执行方式为0
+	facts    HostFacts
+	lastUsed time.Time
+}
+
+// factsCache 按主机缓存的主机信息，SSH连接变化后重新读取
+type factsCache struct {
+	mu      sync.Mutex
+	entries map[string]*factsEntry
+}
+
+// get 返回host的主机信息，缓存中没有或连接已变化时通过exec读取
+// 读取失败时使用默认值但不缓存，下次采集时重新读取
+func (f *factsCache) get(ctx context.Context, host string, exec transport.Executor) HostFacts {
+	var connID uint64
+	if client, ok := exec.(*sshclient.Client); ok {
+		connID = client.ConnectionID()
+	}
+
+	now := time.Now()
+	f.mu.Lock()
+	if entry, ok := f.entries[host]; ok && entry.connID == connID {
+		entry.lastUsed = now
+		f.mu.Unlock()
+		return entry.facts
+	}
+	f.mu.Unlock()
+
+	facts, ok := readHostFacts(ctx, host, exec)
+	if !ok {
+		return facts
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.entries == nil {
+		f.entries = make(map[string]*factsEntry)
+	}
+	f.entries[host] = &factsEntry{connID: connID, facts: facts, lastUsed: now}
+	// 清理不再采集的主机（例如 /probe 的临时目标）
+	for h, entry := range f.entries {
+		if now.Sub(entry.lastUsed) > hostFactsMaxAge {
+			delete(f.entries, h)
+		}
+	}
+	return facts
+}
+
+// readHostFacts 读取主机信息，读取失败时返回默认值和false
+func readHostFacts(ctx context.Context, host string, exec transport.Executor) (HostFacts, bool) {
+	facts := HostFacts{ClockTicks: defaultClockTicks}
+
+	output, err := runCommand(ctx, exec, "getconf CLK_TCK")
+	if err != nil {
+		logger.Printf("Failed to read CLK_TCK on %s, assuming %.0f: %v", host, defaultClockTicks, err)
+		return facts, false
+	}
+	ticks, err := strconv.ParseFloat(strings.TrimSpace(output), 64)
+	if err != nil || ticks <= 0 {
+		logger.Printf("Invalid CLK_TCK %q on %s, assuming %.0f", strings.TrimSpace(output), host, defaultClockTicks)
+		return facts, false
+	}
+	facts.ClockTicks = ticks
+	return facts, true
+}
@@ -1,19 +1,27 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"ssh_exporter/config"
-	sshclient "ssh_exporter/ssh"
+	"ssh_exporter/transport"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
 )
 
+func init() {
+	Register("files", newFileMonitor)
+}
+
 // FileInfo 文件信息
 type FileInfo struct {
 	Path         string
@@ -21,14 +29,159 @@ type FileInfo struct {
 	LastModified float64
 }
 
+// fileSpec 文件监控配置
+type fileSpec struct {
+	Path   string      `yaml:"path"`   // 要监控的目录
+	Labels []fileLabel `yaml:"labels"` // 文件标签匹配规则
+}
+
+// fileLabel 文件标签配置
+type fileLabel struct {
+	Pattern string `yaml:"pattern"` // 正则表达式
+	Name    string `yaml:"name"`    // 标签名称
+	Value   string `yaml:"value"`   // 标签值
+
+	regexp *regexp.Regexp // 解析配置时编译的Pattern
+}
+
+// fileMonitor 文件监控器
+// 文件指标的标签为 host、path、filename 以及所有主机的文件标签规则中出现的标签
+type fileMonitor struct {
+	prefix string
+
+	mu         sync.Mutex
+	labelNames map[string]bool // Decode时收集的额外标签名称
+
+	once             sync.Once
+	extraLabels      []string // 排序后的额外标签名称，首次使用时确定
+	fileSize         *prometheus.Desc
+	fileLastModified *prometheus.Desc
+	fileAgeMinutes   *prometheus.Desc
+}
+
+// newFileMonitor 创建文件监控器
+func newFileMonitor(prefix string) Monitor {
+	return &fileMonitor{
+		prefix:     prefix,
+		labelNames: make(map[string]bool),
+	}
+}
+
+// labelNameRegexp 合法的Prometheus标签名称
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedFileLabels 文件指标固定使用的标签，不能被文件标签规则覆盖
+var reservedFileLabels = map[string]bool{"host": true, "path": true, "filename": true}
+
+// Decode 解析文件监控配置（列表），编译标签规则中的正则表达式并记录标签名称
+func (m *fileMonitor) Decode(node *yaml.Node) (any, error) {
+	var specs []fileSpec
+	if err := node.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("failed to decode file monitors: %w", err)
+	}
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	for i := range specs {
+		for j := range specs[i].Labels {
+			label := &specs[i].Labels[j]
+			if !labelNameRegexp.MatchString(label.Name) || strings.HasPrefix(label.Name, "__") {
+				return nil, fmt.Errorf("invalid file label name %q", label.Name)
+			}
+			if reservedFileLabels[label.Name] {
+				return nil, fmt.Errorf("file label name %q is reserved", label.Name)
+			}
+			re, err := regexp.Compile(label.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q for file label %s: %w", label.Pattern, label.Name, err)
+			}
+			label.regexp = re
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, spec := range specs {
+		for _, label := range spec.Labels {
+			m.labelNames[label.Name] = true
+		}
+	}
+	return specs, nil
+}
+
+// descs 在所有配置解析完成后创建指标描述
+func (m *fileMonitor) descs() {
+	m.once.Do(func() {
+		m.mu.Lock()
+		for name := range m.labelNames {
+			m.extraLabels = append(m.extraLabels, name)
+		}
+		m.mu.Unlock()
+		sort.Strings(m.extraLabels)
+
+		labels := append([]string{"host", "path", "filename"}, m.extraLabels...)
+		m.fileSize = prometheus.NewDesc(
+			m.prefix+"file_size_bytes",
+			"File size in bytes",
+			labels,
+			nil,
+		)
+		m.fileLastModified = prometheus.NewDesc(
+			m.prefix+"file_last_modified_timestamp",
+			"Last modified timestamp of file",
+			labels,
+			nil,
+		)
+		m.fileAgeMinutes = prometheus.NewDesc(
+			m.prefix+"file_age_minutes",
+			"Minutes since last modification",
+			labels,
+			nil,
+		)
+	})
+}
+
+// Describe 实现Monitor接口
+func (m *fileMonitor) Describe(ch chan<- *prometheus.Desc) {
+	m.descs()
+	ch <- m.fileSize
+	ch <- m.fileLastModified
+	ch <- m.fileAgeMinutes
+}
+
+// Collect 实现Monitor接口
+func (m *fileMonitor) Collect(ctx context.Context, exec transport.Executor, host string, spec any, ch chan<- prometheus.Metric) error {
+	m.descs()
+	currentTime := float64(time.Now().Unix())
+
+	var errs []error
+	for _, monitor := range spec.([]fileSpec) {
+		errs = append(errs, m.collectFileMetrics(ctx, exec, host, monitor, ch, currentTime))
+	}
+	return errors.Join(errs...)
+}
+
+// Commands 实现CommandLister接口
+func (m *fileMonitor) Commands(spec any) []string {
+	var commands []string
+	for _, monitor := range spec.([]fileSpec) {
+		commands = append(commands, fileCommand(monitor.Path))
+	}
+	return commands
+}
+
+// fileCommand 返回列出目录中文件信息的命令
+// 先保存ls的输出，使目录不存在等错误能通过退出码和stderr反映出来
+func fileCommand(path string) string {
+	return fmt.Sprintf("out=$(ls -lgb --full-time %s) || exit $?; printf '%%s\\n' \"$out\" | awk '{print $4\"\\t\"$5\" \"$6\"\\t\"$8}'", path)
+}
+
 // collectFileMetrics 收集文件监控指标
-func (c *SSHCollector) collectFileMetrics(client *sshclient.Client, host string, monitor config.FileMonitor, ch chan<- prometheus.Metric, currentTime float64) {
-	// 执行ls命令获取文件信息
-	command := fmt.Sprintf("ls -lgb --full-time %s 2>/dev/null | awk '{print $4\"\\t\"$5\" \"$6\"\\t\"$8}'", monitor.Path)
-	output, err := client.ExecuteCommand(command)
+func (m *fileMonitor) collectFileMetrics(ctx context.Context, exec transport.Executor, host string, monitor fileSpec, ch chan<- prometheus.Metric, currentTime float64) error {
+	output, err := runCommand(ctx, exec, fileCommand(monitor.Path))
 	if err != nil {
-		logger.Printf("Failed to get file info on %s: %v", host, err)
-		return
+		return err
 	}
 
 	// 解析输出
@@ -38,34 +191,36 @@ func (c *SSHCollector) collectFileMetrics(client *sshclient.Client, host string,
 	for _, info := range fileInfos {
 		filename := filepath.Base(info.Path)
 
-		// 应用标签匹配规则（如果有）
-		// 注意：这里不需要添加额外的标签到metric中，因为Prometheus的Desc已经定义了固定的标签
+		// 应用标签匹配规则，额外标签在Desc中已统一声明
+		labelValues := append([]string{host, monitor.Path, filename}, fileLabelValues(filename, monitor.Labels, m.extraLabels)...)
 
 		// 文件大小
 		ch <- prometheus.MustNewConstMetric(
-			c.fileSize,
+			m.fileSize,
 			prometheus.GaugeValue,
 			float64(info.Size),
-			host, monitor.Path, filename,
+			labelValues...,
 		)
 
 		// 最后修改时间
 		ch <- prometheus.MustNewConstMetric(
-			c.fileLastModified,
+			m.fileLastModified,
 			prometheus.GaugeValue,
 			info.LastModified,
-			host, monitor.Path, filename,
+			labelValues...,
 		)
 
 		// 文件年龄（分钟）
 		ageMinutes := (currentTime - info.LastModified) / 60
 		ch <- prometheus.MustNewConstMetric(
-			c.fileAgeMinutes,
+			m.fileAgeMinutes,
 			prometheus.GaugeValue,
 			ageMinutes,
-			host, monitor.Path, filename,
+			labelValues...,
 		)
 	}
+
+	return nil
 }
 
 // parseFileOutput 解析文件输出
@@ -119,12 +274,17 @@ func parseFileOutput(output string, basePath string) []FileInfo {
 	return fileInfos
 }
 
-// matchesPattern 检查文件名是否匹配正则表达式
-func matchesPattern(filename, pattern string) bool {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		logger.Printf("Invalid regex pattern '%s': %v", pattern, err)
-		return false
+// fileLabelValues 按规则计算文件的额外标签值，names为全部额外标签名称
+// 同一标签有多条规则匹配时使用第一条，未匹配的标签值为空
+func fileLabelValues(filename string, rules []fileLabel, names []string) []string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		for _, rule := range rules {
+			if rule.Name == name && rule.regexp != nil && rule.regexp.MatchString(filename) {
+				values[i] = rule.Value
+				break
+			}
+		}
 	}
-	return re.MatchString(filename)
+	return values
 }
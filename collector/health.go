@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ssh_exporter/transport"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// 命令失败原因，用于 command_errors_total 的 reason 标签
+const (
+	reasonExec     = "exec"     // 命令执行失败
+	reasonParse    = "parse"    // 命令输出无法解析
+	reasonTimeout  = "timeout"  // 命令执行超时
+	reasonCanceled = "canceled" // 抓取请求被取消
+	reasonExitCode = "exit"     // 命令退出码非0或被信号终止
+	reasonStderr   = "stderr"   // 命令向stderr输出了内容
+)
+
+// commandError 带失败原因的监控错误
+type commandError struct {
+	reason   string
+	exitCode int // 命令的退出码（reason为exit时有效）
+	err      error
+}
+
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// execError 创建命令执行失败错误，超时和取消使用单独的原因
+func execError(command string, err error) error {
+	reason := reasonExec
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		reason = reasonTimeout
+	case errors.Is(err, context.Canceled):
+		reason = reasonCanceled
+	}
+	return &commandError{reason: reason, err: fmt.Errorf("command %q failed: %w", command, err)}
+}
+
+// runCommand 执行命令并返回stdout，命令无法执行、退出码非0或stderr有输出时返回错误
+func runCommand(ctx context.Context, exec transport.Executor, command string) (string, error) {
+	result, err := exec.ExecuteCommand(ctx, command)
+	if err != nil {
+		return "", execError(command, err)
+	}
+
+	// 错误信息中只保留stderr的第一行
+	stderr := strings.TrimSpace(result.Stderr)
+	if i := strings.IndexByte(stderr, '\n'); i >= 0 {
+		stderr = stderr[:i] + " ..."
+	}
+	if result.ExitCode != 0 || result.Signal != "" {
+		status := fmt.Sprintf("exit code %d", result.ExitCode)
+		if result.Signal != "" {
+			status = "signal " + result.Signal
+		}
+		if stderr != "" {
+			status += ": " + stderr
+		}
+		return "", &commandError{
+			reason:   reasonExitCode,
+			exitCode: result.ExitCode,
+			err:      fmt.Errorf("command %q failed with %s", command, status),
+		}
+	}
+	if stderr != "" {
+		return "", &commandError{reason: reasonStderr, err: fmt.Errorf("command %q wrote to stderr: %s", command, stderr)}
+	}
+	return result.Stdout, nil
+}
+
+// parseError 创建输出解析失败错误
+func parseError(format string, args ...any) error {
+	return &commandError{reason: reasonParse, err: fmt.Errorf(format, args...)}
+}
+
+// errorReasons 展开（可能由errors.Join合并的）错误并返回每个错误的原因
+func errorReasons(err error) []string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var reasons []string
+		for _, e := range joined.Unwrap() {
+			reasons = append(reasons, errorReasons(e)...)
+		}
+		return reasons
+	}
+
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		return []string{cmdErr.reason}
+	}
+	return []string{reasonExec}
+}
+
+// exitCode 返回错误中第一个非0退出码，没有因退出码失败的命令时返回0
+func exitCode(err error) int {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if code := exitCode(e); code != 0 {
+				return code
+			}
+		}
+		return 0
+	}
+
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) && cmdErr.reason == reasonExitCode {
+		return cmdErr.exitCode
+	}
+	return 0
+}
+
+// runMonitor 在timeout内执行一个监控项，输出其耗时和是否成功，并按原因累计失败次数
+func (c *SSHCollector) runMonitor(ctx context.Context, host, monitor string, timeout time.Duration, ch chan<- prometheus.Metric, collect func(ctx context.Context) error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := collect(ctx)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		logger.Printf("Monitor %s failed on %s: %v", monitor, host, err)
+		for _, reason := range errorReasons(err) {
+			c.commandErrors.WithLabelValues(host, monitor, reason).Inc()
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.scrapeDuration,
+		prometheus.GaugeValue,
+		duration,
+		host, monitor,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.scrapeSuccess,
+		prometheus.GaugeValue,
+		boolToFloat(err == nil),
+		host, monitor,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.commandExitCode,
+		prometheus.GaugeValue,
+		float64(exitCode(err)),
+		host, monitor,
+	)
+}
+
+// collectHealth 输出累计的错误计数和SSH握手耗时，只输出hosts中主机的指标
+func (c *SSHCollector) collectHealth(hosts map[string]bool, ch chan<- prometheus.Metric) {
+	metricsChan := make(chan prometheus.Metric)
+	go func() {
+		c.commandErrors.Collect(metricsChan)
+		c.sshMetrics.Collect(metricsChan)
+		close(metricsChan)
+	}()
+	for metric := range metricsChan {
+		if hosts[metricLabel(metric, "host")] {
+			ch <- metric
+		}
+	}
+}
+
+// probeTargetMaxAge 超过该时间未探测的目标，其累计的错误计数和握手耗时被删除
+const probeTargetMaxAge = 10 * time.Minute
+
+// touchProbeTarget 记录对host的一次探测，并删除长时间未探测的目标的错误计数和握手耗时
+// /probe 的目标由请求决定，不清理时这些指标会无限增长
+func (c *SSHCollector) touchProbeTarget(host string) {
+	now := time.Now()
+	c.probeMu.Lock()
+	defer c.probeMu.Unlock()
+	if c.probeTargets == nil {
+		c.probeTargets = make(map[string]time.Time)
+	}
+	c.probeTargets[host] = now
+	for h, lastUsed := range c.probeTargets {
+		if now.Sub(lastUsed) <= probeTargetMaxAge {
+			continue
+		}
+		delete(c.probeTargets, h)
+		if !c.configuredHosts[h] {
+			c.commandErrors.DeletePartialMatch(prometheus.Labels{"host": h})
+			c.sshMetrics.Forget(h)
+		}
+	}
+}
+
+// metricLabel 返回指标的标签值，没有该标签时返回空字符串
+func metricLabel(metric prometheus.Metric, name string) string {
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		return ""
+	}
+	for _, label := range m.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
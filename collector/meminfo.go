@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ssh_exporter/transport"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("meminfo", newMeminfoMonitor)
+}
+
+// MeminfoField /proc/meminfo 中的一项
+type MeminfoField struct {
+	Key   string
+	Value float64 // 单位为kB的项已换算为字节
+	Bytes bool    // 是否为字节数，否则为计数（例如 HugePages_Total）
+}
+
+// meminfoSpec meminfo监控配置，可以写作 meminfo: true 或 meminfo: {fields: [...]}
+type meminfoSpec struct {
+	Fields []string `yaml:"fields"` // 只输出这些项（可选，默认输出全部），使用 /proc/meminfo 中的名称
+
+	fields map[string]bool
+}
+
+// meminfoKnownFields 已知的 /proc/meminfo 项，值表示是否以kB为单位
+// 这些项输出为各自的指标，其他项（例如较新内核增加的项）输出为 memory_other_bytes{field} 或 memory_other{field}，
+// 因此所有指标名称都可以在Describe中列出
+var meminfoKnownFields = map[string]bool{
+	"MemTotal": true, "MemFree": true, "MemAvailable": true, "Buffers": true, "Cached": true, "SwapCached": true,
+	"Active": true, "Inactive": true, "Active(anon)": true, "Inactive(anon)": true, "Active(file)": true, "Inactive(file)": true,
+	"Unevictable": true, "Mlocked": true, "HighTotal": true, "HighFree": true, "LowTotal": true, "LowFree": true, "MmapCopy": true,
+	"SwapTotal": true, "SwapFree": true, "Zswap": true, "Zswapped": true, "Dirty": true, "Writeback": true,
+	"AnonPages": true, "Mapped": true, "Shmem": true, "KReclaimable": true, "Slab": true, "SReclaimable": true, "SUnreclaim": true,
+	"KernelStack": true, "ShadowCallStack": true, "PageTables": true, "SecPageTables": true, "NFS_Unstable": true, "Bounce": true,
+	"WritebackTmp": true, "CommitLimit": true, "Committed_AS": true, "VmallocTotal": true, "VmallocUsed": true, "VmallocChunk": true,
+	"Percpu": true, "HardwareCorrupted": true, "AnonHugePages": true, "ShmemHugePages": true, "ShmemPmdMapped": true,
+	"FileHugePages": true, "FilePmdMapped": true, "CmaTotal": true, "CmaFree": true, "Unaccepted": true, "Balloon": true,
+	"Hugepagesize": true, "Hugetlb": true, "DirectMap4k": true, "DirectMap4M": true, "DirectMap2M": true, "DirectMap1G": true,
+	"HugePages_Total": false, "HugePages_Free": false, "HugePages_Rsvd": false, "HugePages_Surp": false,
+}
+
+// meminfoMonitor 将 /proc/meminfo 的每一项输出为 memory_<key>_bytes（计数项为 memory_<key>）
+type meminfoMonitor struct {
+	descs      map[string]*prometheus.Desc // 已知项的描述，按项名称索引
+	otherBytes *prometheus.Desc            // 未知的字节数项
+	other      *prometheus.Desc            // 未知的计数项
+}
+
+// newMeminfoMonitor 创建meminfo监控器
+func newMeminfoMonitor(prefix string) Monitor {
+	m := &meminfoMonitor{
+		descs: make(map[string]*prometheus.Desc, len(meminfoKnownFields)),
+		otherBytes: prometheus.NewDesc(
+			prefix+"memory_other_bytes",
+			"Memory information field from /proc/meminfo not known to the exporter, in bytes",
+			[]string{"host", "field"},
+			nil,
+		),
+		other: prometheus.NewDesc(
+			prefix+"memory_other",
+			"Memory information field from /proc/meminfo not known to the exporter, without a unit",
+			[]string{"host", "field"},
+			nil,
+		),
+	}
+	for key, bytes := range meminfoKnownFields {
+		m.descs[key] = prometheus.NewDesc(
+			prefix+meminfoMetricName(key, bytes),
+			"Memory information field "+key+" from /proc/meminfo",
+			[]string{"host"},
+			nil,
+		)
+	}
+	return m
+}
+
+// Decode 解析meminfo监控配置
+func (m *meminfoMonitor) Decode(node *yaml.Node) (any, error) {
+	var spec meminfoSpec
+	if node.Kind == yaml.MappingNode {
+		if err := node.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode meminfo monitor: %w", err)
+		}
+	} else {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return nil, fmt.Errorf("failed to decode meminfo monitor: %w", err)
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+
+	if len(spec.Fields) > 0 {
+		spec.fields = make(map[string]bool, len(spec.Fields))
+		for _, field := range spec.Fields {
+			if field == "" {
+				return nil, fmt.Errorf("empty meminfo field name")
+			}
+			spec.fields[field] = true
+		}
+	}
+	return &spec, nil
+}
+
+// Describe 实现Monitor接口
+func (m *meminfoMonitor) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range m.descs {
+		ch <- desc
+	}
+	ch <- m.otherBytes
+	ch <- m.other
+}
+
+// Commands 实现CommandLister接口
+func (m *meminfoMonitor) Commands(spec any) []string {
+	return []string{meminfoCommand}
+}
+
+// Collect 实现Monitor接口
+func (m *meminfoMonitor) Collect(ctx context.Context, exec transport.Executor, host string, spec any, ch chan<- prometheus.Metric) error {
+	meminfoSpec := spec.(*meminfoSpec)
+
+	output, err := runCommand(ctx, exec, meminfoCommand)
+	if err != nil {
+		return err
+	}
+
+	fields := parseMeminfo(output)
+	if len(fields) == 0 {
+		return parseError("failed to parse /proc/meminfo")
+	}
+
+	count := 0
+	for _, field := range fields {
+		if meminfoSpec.fields != nil && !meminfoSpec.fields[field.Key] {
+			continue
+		}
+		count++
+		// 单位与已知的不一致时同样作为未知项输出，避免同一指标名称的含义不同
+		if bytes, ok := meminfoKnownFields[field.Key]; ok && bytes == field.Bytes {
+			ch <- prometheus.MustNewConstMetric(m.descs[field.Key], prometheus.GaugeValue, field.Value, host)
+		} else if field.Bytes {
+			ch <- prometheus.MustNewConstMetric(m.otherBytes, prometheus.GaugeValue, field.Value, host, field.Key)
+		} else {
+			ch <- prometheus.MustNewConstMetric(m.other, prometheus.GaugeValue, field.Value, host, field.Key)
+		}
+	}
+
+	logger.Printf("Collected %d meminfo fields on %s", count, host)
+	return nil
+}
+
+// meminfoInvalidChars 指标名称中不允许的字符
+var meminfoInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// meminfoMetricName 返回meminfo项对应的指标名称（不含前缀）
+// 与node_exporter一致，括号中的部分用下划线连接，例如 Active(anon) 为 memory_Active_anon_bytes
+func meminfoMetricName(key string, bytes bool) string {
+	key = strings.ReplaceAll(strings.ReplaceAll(key, "(", "_"), ")", "")
+	name := "memory_" + meminfoInvalidChars.ReplaceAllString(key, "_")
+	if bytes {
+		name += "_bytes"
+	}
+	return name
+}
+
+// parseMeminfo 解析 /proc/meminfo 输出
+// 格式: "Key:   value kB"，计数项没有单位
+func parseMeminfo(output string) []MeminfoField {
+	var fields []MeminfoField
+	for _, line := range strings.Split(output, "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Fields(rest)
+		if len(parts) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			continue
+		}
+		field := MeminfoField{Key: strings.TrimSpace(key), Value: value}
+		if len(parts) > 1 && parts[1] == "kB" {
+			field.Value *= 1024
+			field.Bytes = true
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"ssh_exporter/transport"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// staticExecutor 测试用执行器，返回预设的命令输出
+type staticExecutor map[string]string
+
+// ExecuteCommand 实现transport.Executor接口
+func (e staticExecutor) ExecuteCommand(ctx context.Context, command string) (transport.Result, error) {
+	return transport.Result{Stdout: e[command]}, nil
+}
+
+// monitorCollector 将单个监控器包装为prometheus.Collector
+type monitorCollector struct {
+	monitor Monitor
+	spec    any
+	exec    transport.Executor
+}
+
+func (c *monitorCollector) Describe(ch chan<- *prometheus.Desc) { c.monitor.Describe(ch) }
+
+func (c *monitorCollector) Collect(ch chan<- prometheus.Metric) {
+	c.monitor.Collect(context.Background(), c.exec, "test", c.spec, ch)
+}
+
+func TestMeminfoDescribed(t *testing.T) {
+	exec := staticExecutor{meminfoCommand: `MemTotal:       16 kB
+Active(anon):    4 kB
+HugePages_Total:       2
+NewField:        8 kB
+NewCount:        3
+MemFree:         5
+`}
+
+	registry := prometheus.NewPedanticRegistry()
+	monitor := newMeminfoMonitor("")
+	if err := registry.Register(&monitorCollector{monitor: monitor, spec: &meminfoSpec{}, exec: exec}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	// 未在Describe中列出的指标会使Gather返回错误
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	type series struct{ name, field string }
+	got := make(map[series]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			s := series{name: family.GetName()}
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "field" {
+					s.field = label.GetValue()
+				}
+			}
+			got[s] = metric.GetGauge().GetValue()
+		}
+	}
+
+	want := map[series]float64{
+		{name: "memory_MemTotal_bytes"}:                 16 * 1024,
+		{name: "memory_Active_anon_bytes"}:              4 * 1024,
+		{name: "memory_HugePages_Total"}:                2,
+		{name: "memory_other_bytes", field: "NewField"}: 8 * 1024,
+		{name: "memory_other", field: "NewCount"}:       3,
+		// 单位与已知项不一致
+		{name: "memory_other", field: "MemFree"}: 5,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d series, want %d: %v", len(got), len(want), got)
+	}
+	for s, value := range want {
+		if got[s] != value {
+			t.Errorf("%s{field=%q} = %v, want %v", s.name, s.field, got[s], value)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"ssh_exporter/config"
+	"ssh_exporter/transport"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+// Monitor 监控器接口，每种监控器在一个SSHCollector中只有一个实例，
+// 各主机的配置通过Decode解析后在Collect时传回
+type Monitor interface {
+	// Decode 解析主机 monitors 下该监控器对应的配置节点
+	Decode(node *yaml.Node) (any, error)
+	// Describe 输出该监控器的全部指标描述，在所有配置解析完成后调用
+	Describe(ch chan<- *prometheus.Desc)
+	// Collect 通过exec采集host的指标，spec为Decode的返回值
+	Collect(ctx context.Context, exec transport.Executor, host string, spec any, ch chan<- prometheus.Metric) error
+}
+
+// Factory 创建监控器，prefix为指标名称前缀
+type Factory func(prefix string) Monitor
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册监控器，name为配置中 monitors 下的键，通常在包的init函数中调用
+// 重复注册同一名称会panic
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("collector: monitor %q already registered", name))
+	}
+	registry[name] = factory
+}
+
+// newMonitors 使用指标前缀创建所有已注册的监控器
+func newMonitors(prefix string) map[string]Monitor {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	monitors := make(map[string]Monitor, len(registry))
+	for name, factory := range registry {
+		monitors[name] = factory(prefix)
+	}
+	return monitors
+}
+
+// boundMonitor 某个主机上启用的监控器及其配置
+type boundMonitor struct {
+	name    string
+	monitor Monitor
+	spec    any
+}
+
+// decodeMonitors 解析主机的监控配置，返回按名称排序的监控器列表
+func decodeMonitors(monitors map[string]Monitor, cfg config.MonitorConfig) ([]boundMonitor, error) {
+	names := make([]string, 0, len(cfg))
+	for name := range cfg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bound := make([]boundMonitor, 0, len(names))
+	for _, name := range names {
+		monitor, ok := monitors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown monitor %q", name)
+		}
+		node := cfg[name]
+		spec, err := monitor.Decode(&node)
+		if err != nil {
+			return nil, fmt.Errorf("monitor %s: %w", name, err)
+		}
+		if spec == nil {
+			// 监控器被显式禁用（例如 stat: false）
+			continue
+		}
+		bound = append(bound, boundMonitor{name: name, monitor: monitor, spec: spec})
+	}
+	return bound, nil
+}
@@ -0,0 +1,351 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ssh_exporter/transport"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("network", newNetworkMonitor)
+}
+
+// NetDevStats 网络接口统计信息（/proc/net/dev 的一行）
+type NetDevStats struct {
+	Device    string
+	RxBytes   float64
+	RxPackets float64
+	RxErrs    float64
+	RxDrop    float64
+	TxBytes   float64
+	TxPackets float64
+	TxErrs    float64
+	TxDrop    float64
+}
+
+// NetLinkInfo 网络接口的链路信息（/sys/class/net）
+type NetLinkInfo struct {
+	Device    string
+	Speed     float64 // 速率（Mbit/s），未知时为-1
+	OperState string
+}
+
+// deviceFilter 按名称筛选设备的正则表达式，include为空时包含所有设备
+type deviceFilter struct {
+	Include string `yaml:"include"` // 只包含名称匹配的设备（可选）
+	Exclude string `yaml:"exclude"` // 排除名称匹配的设备（可选）
+
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// compile 编译筛选规则中的正则表达式
+func (f *deviceFilter) compile() error {
+	var err error
+	if f.Include != "" {
+		if f.include, err = regexp.Compile(f.Include); err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", f.Include, err)
+		}
+	}
+	if f.Exclude != "" {
+		if f.exclude, err = regexp.Compile(f.Exclude); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", f.Exclude, err)
+		}
+	}
+	return nil
+}
+
+// match 判断设备是否需要采集
+func (f *deviceFilter) match(device string) bool {
+	if f.include != nil && !f.include.MatchString(device) {
+		return false
+	}
+	return f.exclude == nil || !f.exclude.MatchString(device)
+}
+
+// networkSpec 网络监控配置，可以写作 network: true 或带筛选规则的映射
+type networkSpec struct {
+	deviceFilter `yaml:",inline"`
+
+	LinkInfo bool `yaml:"link_info"` // 从 /sys/class/net 读取链路速率和operstate
+}
+
+// 网络监控使用的命令
+const (
+	netDevCommand = "cat /proc/net/dev"
+	// 每个接口输出一行：名称 速率 operstate；接口down时读取speed会失败，输出-1
+	// 接口是（指向）目录的符号链接，bonding_masters等普通文件被跳过
+	netLinkCommand = `for d in /sys/class/net/*; do [ -d "$d" ] || continue; printf '%s %s %s\n' "${d##*/}" "$(cat "$d/speed" 2>/dev/null || echo -1)" "$(cat "$d/operstate" 2>/dev/null || echo unknown)"; done`
+)
+
+// networkMonitor 网络接口监控器
+type networkMonitor struct {
+	receiveBytes    *prometheus.Desc
+	receivePackets  *prometheus.Desc
+	receiveErrs     *prometheus.Desc
+	receiveDrop     *prometheus.Desc
+	transmitBytes   *prometheus.Desc
+	transmitPackets *prometheus.Desc
+	transmitErrs    *prometheus.Desc
+	transmitDrop    *prometheus.Desc
+
+	speedBytes *prometheus.Desc
+	up         *prometheus.Desc
+	info       *prometheus.Desc
+}
+
+// newNetworkMonitor 创建网络接口监控器
+func newNetworkMonitor(prefix string) Monitor {
+	return &networkMonitor{
+		receiveBytes: prometheus.NewDesc(
+			prefix+"network_receive_bytes_total",
+			"Bytes received on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		receivePackets: prometheus.NewDesc(
+			prefix+"network_receive_packets_total",
+			"Packets received on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		receiveErrs: prometheus.NewDesc(
+			prefix+"network_receive_errs_total",
+			"Receive errors on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		receiveDrop: prometheus.NewDesc(
+			prefix+"network_receive_drop_total",
+			"Received packets dropped on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		transmitBytes: prometheus.NewDesc(
+			prefix+"network_transmit_bytes_total",
+			"Bytes transmitted on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		transmitPackets: prometheus.NewDesc(
+			prefix+"network_transmit_packets_total",
+			"Packets transmitted on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		transmitErrs: prometheus.NewDesc(
+			prefix+"network_transmit_errs_total",
+			"Transmit errors on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		transmitDrop: prometheus.NewDesc(
+			prefix+"network_transmit_drop_total",
+			"Transmitted packets dropped on the network interface",
+			[]string{"host", "device"},
+			nil,
+		),
+		speedBytes: prometheus.NewDesc(
+			prefix+"network_speed_bytes",
+			"Link speed of the network interface in bytes per second",
+			[]string{"host", "device"},
+			nil,
+		),
+		up: prometheus.NewDesc(
+			prefix+"network_up",
+			"Whether the operstate of the network interface is up (1: up, 0: other)",
+			[]string{"host", "device"},
+			nil,
+		),
+		info: prometheus.NewDesc(
+			prefix+"network_info",
+			"Operstate of the network interface",
+			[]string{"host", "device", "operstate"},
+			nil,
+		),
+	}
+}
+
+// Decode 解析网络监控配置，编译筛选规则中的正则表达式
+func (m *networkMonitor) Decode(node *yaml.Node) (any, error) {
+	var spec networkSpec
+	if node.Kind == yaml.MappingNode {
+		if err := node.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode network monitor: %w", err)
+		}
+	} else {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return nil, fmt.Errorf("failed to decode network monitor: %w", err)
+		}
+		if !enabled {
+			return nil, nil
+		}
+	}
+
+	if err := spec.compile(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Describe 实现Monitor接口
+func (m *networkMonitor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.receiveBytes
+	ch <- m.receivePackets
+	ch <- m.receiveErrs
+	ch <- m.receiveDrop
+	ch <- m.transmitBytes
+	ch <- m.transmitPackets
+	ch <- m.transmitErrs
+	ch <- m.transmitDrop
+	ch <- m.speedBytes
+	ch <- m.up
+	ch <- m.info
+}
+
+// Commands 实现CommandLister接口
+func (m *networkMonitor) Commands(spec any) []string {
+	if spec.(*networkSpec).LinkInfo {
+		return []string{netDevCommand, netLinkCommand}
+	}
+	return []string{netDevCommand}
+}
+
+// Collect 实现Monitor接口
+func (m *networkMonitor) Collect(ctx context.Context, exec transport.Executor, host string, spec any, ch chan<- prometheus.Metric) error {
+	networkSpec := spec.(*networkSpec)
+	err := m.collectNetDevMetrics(ctx, exec, host, networkSpec, ch)
+	if networkSpec.LinkInfo {
+		err = errors.Join(err, m.collectLinkMetrics(ctx, exec, host, networkSpec, ch))
+	}
+	return err
+}
+
+// collectNetDevMetrics 收集 /proc/net/dev 中的接口计数器
+func (m *networkMonitor) collectNetDevMetrics(ctx context.Context, exec transport.Executor, host string, spec *networkSpec, ch chan<- prometheus.Metric) error {
+	output, err := runCommand(ctx, exec, netDevCommand)
+	if err != nil {
+		return err
+	}
+
+	stats := parseNetDevStats(output)
+	if stats == nil {
+		return parseError("failed to parse /proc/net/dev")
+	}
+
+	count := 0
+	for _, s := range stats {
+		if !spec.match(s.Device) {
+			continue
+		}
+		count++
+		counters := []struct {
+			desc  *prometheus.Desc
+			value float64
+		}{
+			{m.receiveBytes, s.RxBytes},
+			{m.receivePackets, s.RxPackets},
+			{m.receiveErrs, s.RxErrs},
+			{m.receiveDrop, s.RxDrop},
+			{m.transmitBytes, s.TxBytes},
+			{m.transmitPackets, s.TxPackets},
+			{m.transmitErrs, s.TxErrs},
+			{m.transmitDrop, s.TxDrop},
+		}
+		for _, c := range counters {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, c.value, host, s.Device)
+		}
+	}
+
+	logger.Printf("Collected network metrics for %d interfaces on %s", count, host)
+	return nil
+}
+
+// collectLinkMetrics 收集 /sys/class/net 中的链路速率和operstate
+func (m *networkMonitor) collectLinkMetrics(ctx context.Context, exec transport.Executor, host string, spec *networkSpec, ch chan<- prometheus.Metric) error {
+	output, err := runCommand(ctx, exec, netLinkCommand)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range parseNetLinkInfo(output) {
+		if !spec.match(link.Device) {
+			continue
+		}
+		if link.Speed >= 0 {
+			// speed的单位是Mbit/s
+			ch <- prometheus.MustNewConstMetric(m.speedBytes, prometheus.GaugeValue, link.Speed*1000*1000/8, host, link.Device)
+		}
+		up := 0.0
+		if link.OperState == "up" {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.up, prometheus.GaugeValue, up, host, link.Device)
+		ch <- prometheus.MustNewConstMetric(m.info, prometheus.GaugeValue, 1, host, link.Device, link.OperState)
+	}
+	return nil
+}
+
+// parseNetDevStats 解析 /proc/net/dev 输出
+// 格式: 前两行为表头，之后每行为 "接口名: 接收8列 发送8列"，没有找到表头时返回nil
+func parseNetDevStats(output string) []NetDevStats {
+	lines := strings.Split(output, "\n")
+	if len(lines) < 2 || !strings.Contains(lines[1], "|") {
+		return nil
+	}
+
+	stats := []NetDevStats{}
+	for _, line := range lines[2:] {
+		// 计数很大时接口名和冒号后的数字之间可能没有空格
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			continue
+		}
+		values := make([]float64, 16)
+		for i := range values {
+			values[i], _ = strconv.ParseFloat(fields[i], 64)
+		}
+		stats = append(stats, NetDevStats{
+			Device:    strings.TrimSpace(name),
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrs:    values[2],
+			RxDrop:    values[3],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrs:    values[10],
+			TxDrop:    values[11],
+		})
+	}
+	return stats
+}
+
+// parseNetLinkInfo 解析netLinkCommand的输出
+func parseNetLinkInfo(output string) []NetLinkInfo {
+	var links []NetLinkInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		speed, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			speed = -1
+		}
+		links = append(links, NetLinkInfo{Device: fields[0], Speed: speed, OperState: fields[2]})
+	}
+	return links
+}
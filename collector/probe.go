@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ssh_exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProbeCollector 采集单个目标的Collector，用于 /probe 端点
+type ProbeCollector struct {
+	parent  *SSHCollector
+	ctx     context.Context
+	target  hostTarget
+	timeout time.Duration
+}
+
+// NewProbeCollector 使用模块module创建采集单个目标的Collector，hostConfig为已填入目标地址的模块配置
+// 连接池、监控器和指标描述与SSHCollector共享；ctx被取消或超过timeout时终止正在执行的命令
+func (c *SSHCollector) NewProbeCollector(ctx context.Context, module string, hostConfig config.HostConfig, timeout time.Duration) (*ProbeCollector, error) {
+	monitors, ok := c.modules[module]
+	if !ok {
+		return nil, fmt.Errorf("unknown module %q", module)
+	}
+	return &ProbeCollector{
+		parent:  c,
+		ctx:     ctx,
+		target:  hostTarget{config: hostConfig, monitors: monitors},
+		timeout: timeout,
+	}, nil
+}
+
+// Describe 实现Prometheus Collector接口
+// 与contextCollector相同，指标描述在启动时注册SSHCollector时已经检查过，这里不输出描述
+func (p *ProbeCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect 实现Prometheus Collector接口
+// 超过timeout后不再等待采集结果，已收到的指标照常输出，probe_success为0
+func (p *ProbeCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	p.parent.touchProbeTarget(p.target.config.Host)
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+
+	metricsChan := make(chan prometheus.Metric, 100)
+	successChan := make(chan bool, 1)
+
+	go func() {
+		successChan <- p.parent.collectHostMetrics(ctx, p.target, metricsChan)
+		close(metricsChan)
+	}()
+
+	success := false
+	func() {
+		for {
+			select {
+			case metric, ok := <-metricsChan:
+				if !ok {
+					success = <-successChan
+					return
+				}
+				ch <- metric
+			case <-ctx.Done():
+				logger.Printf("Probe of %s ended before collection finished: %v", p.target.config.Host, ctx.Err())
+				// 丢弃超时后仍在产生的指标，避免采集goroutine阻塞
+				go func() {
+					for range metricsChan {
+					}
+				}()
+				return
+			}
+		}
+	}()
+
+	p.parent.collectHealth(map[string]bool{p.target.config.Host: true}, ch)
+	ch <- prometheus.MustNewConstMetric(p.parent.probeSuccess, prometheus.GaugeValue, boolToFloat(success))
+	ch <- prometheus.MustNewConstMetric(p.parent.probeDuration, prometheus.GaugeValue, time.Since(start).Seconds())
+}
+
+// boolToFloat 将布尔值转换为指标值
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,22 +1,86 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
 
-	"ssh_exporter/config"
-	sshclient "ssh_exporter/ssh"
+	"ssh_exporter/transport"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
 )
 
+func init() {
+	Register("processes", newProcessMonitor)
+}
+
+// processSpec 进程监控配置
+type processSpec struct {
+	Patterns []string `yaml:"patterns"` // 要搜索的进程名称模式列表
+}
+
+// processMonitor 进程监控器，统计cmdline中包含各模式的进程数
+type processMonitor struct {
+	processPatternCount *prometheus.Desc
+}
+
+// newProcessMonitor 创建进程监控器
+func newProcessMonitor(prefix string) Monitor {
+	return &processMonitor{
+		processPatternCount: prometheus.NewDesc(
+			prefix+"process_pattern_count",
+			"Count of pattern in process cmdlines",
+			[]string{"host", "pattern"},
+			nil,
+		),
+	}
+}
+
+// Decode 解析进程监控配置（列表）
+func (m *processMonitor) Decode(node *yaml.Node) (any, error) {
+	var specs []processSpec
+	if err := node.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("failed to decode process monitors: %w", err)
+	}
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	return specs, nil
+}
+
+// Describe 实现Monitor接口
+func (m *processMonitor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.processPatternCount
+}
+
+// Collect 实现Monitor接口
+func (m *processMonitor) Collect(ctx context.Context, exec transport.Executor, host string, spec any, ch chan<- prometheus.Metric) error {
+	var errs []error
+	for _, monitor := range spec.([]processSpec) {
+		errs = append(errs, m.collectProcessMetrics(ctx, exec, host, monitor, ch))
+	}
+	return errors.Join(errs...)
+}
+
+// processCommand 获取所有进程cmdline的命令（路径模式固定为 /proc/[0-9]*/cmdline）
+const processCommand = "find /proc -maxdepth 2 -name 'cmdline' -path '/proc/[0-9]*/cmdline' -exec cat {} \\; 2>/dev/null"
+
+// Commands 实现CommandLister接口
+func (m *processMonitor) Commands(spec any) []string {
+	commands := make([]string, len(spec.([]processSpec)))
+	for i := range commands {
+		commands[i] = processCommand
+	}
+	return commands
+}
+
 // collectProcessMetrics 收集进程监控指标
-func (c *SSHCollector) collectProcessMetrics(client *sshclient.Client, host string, monitor config.ProcessMonitor, ch chan<- prometheus.Metric) {
-	// 执行find命令获取进程cmdline（路径模式固定为 /proc/[0-9]*/cmdline）
-	command := "find /proc -maxdepth 2 -name 'cmdline' -path '/proc/[0-9]*/cmdline' -exec cat {} \\; 2>/dev/null"
-	output, err := client.ExecuteCommand(command)
+func (m *processMonitor) collectProcessMetrics(ctx context.Context, exec transport.Executor, host string, monitor processSpec, ch chan<- prometheus.Metric) error {
+	output, err := runCommand(ctx, exec, processCommand)
 	if err != nil {
-		logger.Printf("Failed to get process cmdlines on %s: %v", host, err)
-		return
+		return err
 	}
 
 	// 解析输出
@@ -35,13 +99,15 @@ func (c *SSHCollector) collectProcessMetrics(client *sshclient.Client, host stri
 
 		// 使用统一的指标描述符
 		ch <- prometheus.MustNewConstMetric(
-			c.processPatternCount,
+			m.processPatternCount,
 			prometheus.GaugeValue,
 			float64(count),
 			host,
 			pattern,
 		)
 	}
+
+	return nil
 }
 
 // parseProcessOutput 解析进程输出
@@ -1,15 +1,25 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	sshclient "ssh_exporter/ssh"
+	"ssh_exporter/transport"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
 )
 
+func init() {
+	Register("stat", newStatMonitor)
+}
+
 // CPUStats CPU统计信息
 type CPUStats struct {
 	User         float64
@@ -21,6 +31,47 @@ type CPUStats struct {
 	Intr         float64 // 中断
 	ProcsRunning float64
 	ProcsBlocked float64
+	BootTime     float64 // 启动时间（Unix时间戳）
+
+	Times  CPUTimes            // 汇总cpu行中各模式的时间
+	PerCPU map[string]CPUTimes // 各cpuN行中各模式的时间，键为CPU编号
+}
+
+// CPUTimes 一行cpu统计中各模式的时间（秒）
+// 与 /proc/stat 一致，User和Nice包含Guest和GuestNice
+type CPUTimes struct {
+	User      float64
+	Nice      float64
+	System    float64
+	Idle      float64
+	Iowait    float64
+	Irq       float64
+	Softirq   float64
+	Steal     float64
+	Guest     float64
+	GuestNice float64
+}
+
+// InodeStats 文件系统inode统计信息
+type InodeStats struct {
+	Device     string
+	MountPoint string
+	Total      float64
+	Used       float64
+	Free       float64
+}
+
+// MountInfo /proc/mounts 中的挂载信息
+type MountInfo struct {
+	Device     string
+	MountPoint string
+	FSType     string
+	ReadOnly   bool
+}
+
+// statSpec stat监控配置，可以写作 stat: true 或 stat: {per_cpu: true}
+type statSpec struct {
+	PerCPU bool `yaml:"per_cpu"` // 按CPU核心输出 cpu_seconds_total，默认只输出汇总（cpu="all"）
 }
 
 // MemoryStats 内存统计信息
@@ -43,129 +94,608 @@ type DiskStats struct {
 	UsagePercent float64
 }
 
-// collectStatMetrics 收集系统统计指标 (CPU、内存、磁盘)
-func (c *SSHCollector) collectStatMetrics(client *sshclient.Client, host string, ch chan<- prometheus.Metric, currentTime float64) {
-	// 收集CPU指标
-	c.collectCPUMetrics(client, host, ch)
+// statMonitor 系统统计监控器 (CPU、内存、磁盘)
+type statMonitor struct {
+	mu    sync.Mutex
+	hosts map[string]*statHost // 各主机上一次的CPU统计和启动时间，用于计算CPU使用率和检测重启
+
+	// CPU指标
+	cpuSeconds       *prometheus.Desc
+	cpuGuestSeconds  *prometheus.Desc
+	cpuUserSeconds   *prometheus.Desc
+	cpuSystemSeconds *prometheus.Desc
+	cpuIdleSeconds   *prometheus.Desc
+	cpuIowaitSeconds *prometheus.Desc
+	cpuUsagePercent  *prometheus.Desc
+	contextSwitches  *prometheus.Desc
+	interrupts       *prometheus.Desc
+	processesRunning *prometheus.Desc
+	processesBlocked *prometheus.Desc
 
-	// 收集内存指标
-	c.collectMemoryMetrics(client, host, ch)
+	// 负载和启动时间指标
+	load1         *prometheus.Desc
+	load5         *prometheus.Desc
+	load15        *prometheus.Desc
+	runnableTasks *prometheus.Desc
+	tasks         *prometheus.Desc
+	uptime        *prometheus.Desc
+	bootTime      *prometheus.Desc
+	reboots       *prometheus.Desc
 
-	// 收集磁盘指标
-	c.collectDiskMetrics(client, host, ch)
+	// 内存指标
+	memoryTotalBytes     *prometheus.Desc
+	memoryFreeBytes      *prometheus.Desc
+	memoryAvailableBytes *prometheus.Desc
+	memoryBuffersBytes   *prometheus.Desc
+	memoryCachedBytes    *prometheus.Desc
+	memoryUsagePercent   *prometheus.Desc
+
+	// 磁盘指标
+	diskTotalBytes   *prometheus.Desc
+	diskUsedBytes    *prometheus.Desc
+	diskFreeBytes    *prometheus.Desc
+	diskUsagePercent *prometheus.Desc
+	diskInodes       *prometheus.Desc
+	diskInodesUsed   *prometheus.Desc
+	diskInodesFree   *prometheus.Desc
+	diskReadOnly     *prometheus.Desc
 }
 
-// collectCPUMetrics 收集CPU指标
-func (c *SSHCollector) collectCPUMetrics(client *sshclient.Client, host string, ch chan<- prometheus.Metric) {
-	// 第一次读取 /proc/stat
-	output1, err := client.ExecuteCommand("cat /proc/stat")
-	if err != nil {
-		logger.Printf("Failed to read /proc/stat on %s: %v", host, err)
-		return
+// newStatMonitor 创建系统统计监控器
+func newStatMonitor(prefix string) Monitor {
+	return &statMonitor{
+		hosts: make(map[string]*statHost),
+		cpuSeconds: prometheus.NewDesc(
+			prefix+"cpu_seconds_total",
+			"Seconds the CPUs spent in each mode",
+			[]string{"host", "cpu", "mode"},
+			nil,
+		),
+		cpuGuestSeconds: prometheus.NewDesc(
+			prefix+"cpu_guest_seconds_total",
+			"Seconds the CPUs spent running guests in each mode (included in cpu_seconds_total user and nice)",
+			[]string{"host", "cpu", "mode"},
+			nil,
+		),
+		cpuUserSeconds: prometheus.NewDesc(
+			prefix+"cpu_user_seconds_total",
+			"Total CPU time spent in user mode",
+			[]string{"host"},
+			nil,
+		),
+		cpuSystemSeconds: prometheus.NewDesc(
+			prefix+"cpu_system_seconds_total",
+			"Total CPU time spent in system mode",
+			[]string{"host"},
+			nil,
+		),
+		cpuIdleSeconds: prometheus.NewDesc(
+			prefix+"cpu_idle_seconds_total",
+			"Total CPU idle time",
+			[]string{"host"},
+			nil,
+		),
+		cpuIowaitSeconds: prometheus.NewDesc(
+			prefix+"cpu_iowait_seconds_total",
+			"Total CPU time waiting for I/O",
+			[]string{"host"},
+			nil,
+		),
+		cpuUsagePercent: prometheus.NewDesc(
+			prefix+"cpu_usage_percent",
+			"CPU usage percentage",
+			[]string{"host"},
+			nil,
+		),
+		contextSwitches: prometheus.NewDesc(
+			prefix+"context_switches_total",
+			"Total number of context switches",
+			[]string{"host"},
+			nil,
+		),
+		interrupts: prometheus.NewDesc(
+			prefix+"interrupts_total",
+			"Total number of interrupts",
+			[]string{"host"},
+			nil,
+		),
+		processesRunning: prometheus.NewDesc(
+			prefix+"processes_running",
+			"Number of processes in running state",
+			[]string{"host"},
+			nil,
+		),
+		processesBlocked: prometheus.NewDesc(
+			prefix+"processes_blocked",
+			"Number of processes blocked waiting for I/O",
+			[]string{"host"},
+			nil,
+		),
+		load1: prometheus.NewDesc(
+			prefix+"load1",
+			"1m load average",
+			[]string{"host"},
+			nil,
+		),
+		load5: prometheus.NewDesc(
+			prefix+"load5",
+			"5m load average",
+			[]string{"host"},
+			nil,
+		),
+		load15: prometheus.NewDesc(
+			prefix+"load15",
+			"15m load average",
+			[]string{"host"},
+			nil,
+		),
+		runnableTasks: prometheus.NewDesc(
+			prefix+"load_runnable_tasks",
+			"Number of currently runnable kernel scheduling entities (processes, threads)",
+			[]string{"host"},
+			nil,
+		),
+		tasks: prometheus.NewDesc(
+			prefix+"load_tasks",
+			"Number of kernel scheduling entities (processes, threads) that currently exist",
+			[]string{"host"},
+			nil,
+		),
+		uptime: prometheus.NewDesc(
+			prefix+"uptime_seconds",
+			"Seconds since the host booted",
+			[]string{"host"},
+			nil,
+		),
+		bootTime: prometheus.NewDesc(
+			prefix+"boot_time_seconds",
+			"Boot time of the host as a Unix timestamp",
+			[]string{"host"},
+			nil,
+		),
+		reboots: prometheus.NewDesc(
+			prefix+"reboots_total",
+			"Number of boot time changes seen by the exporter since it started",
+			[]string{"host"},
+			nil,
+		),
+		memoryTotalBytes: prometheus.NewDesc(
+			prefix+"memory_total_bytes",
+			"Total memory in bytes",
+			[]string{"host"},
+			nil,
+		),
+		memoryFreeBytes: prometheus.NewDesc(
+			prefix+"memory_free_bytes",
+			"Free memory in bytes",
+			[]string{"host"},
+			nil,
+		),
+		memoryAvailableBytes: prometheus.NewDesc(
+			prefix+"memory_available_bytes",
+			"Available memory in bytes",
+			[]string{"host"},
+			nil,
+		),
+		memoryBuffersBytes: prometheus.NewDesc(
+			prefix+"memory_buffers_bytes",
+			"Memory used for buffers in bytes",
+			[]string{"host"},
+			nil,
+		),
+		memoryCachedBytes: prometheus.NewDesc(
+			prefix+"memory_cached_bytes",
+			"Memory used for cache in bytes",
+			[]string{"host"},
+			nil,
+		),
+		memoryUsagePercent: prometheus.NewDesc(
+			prefix+"memory_usage_percent",
+			"Memory usage percentage",
+			[]string{"host"},
+			nil,
+		),
+		diskTotalBytes: prometheus.NewDesc(
+			prefix+"disk_total_bytes",
+			"Total disk space in bytes",
+			[]string{"host", "device", "mount_point"},
+			nil,
+		),
+		diskUsedBytes: prometheus.NewDesc(
+			prefix+"disk_used_bytes",
+			"Used disk space in bytes",
+			[]string{"host", "device", "mount_point"},
+			nil,
+		),
+		diskFreeBytes: prometheus.NewDesc(
+			prefix+"disk_free_bytes",
+			"Free disk space in bytes",
+			[]string{"host", "device", "mount_point"},
+			nil,
+		),
+		diskUsagePercent: prometheus.NewDesc(
+			prefix+"disk_usage_percent",
+			"Disk usage percentage",
+			[]string{"host", "device", "mount_point"},
+			nil,
+		),
+		diskInodes: prometheus.NewDesc(
+			prefix+"disk_inodes",
+			"Total number of inodes of the filesystem",
+			[]string{"host", "device", "mount_point", "fstype"},
+			nil,
+		),
+		diskInodesUsed: prometheus.NewDesc(
+			prefix+"disk_inodes_used",
+			"Number of used inodes of the filesystem",
+			[]string{"host", "device", "mount_point", "fstype"},
+			nil,
+		),
+		diskInodesFree: prometheus.NewDesc(
+			prefix+"disk_inodes_free",
+			"Number of free inodes of the filesystem",
+			[]string{"host", "device", "mount_point", "fstype"},
+			nil,
+		),
+		diskReadOnly: prometheus.NewDesc(
+			prefix+"disk_read_only",
+			"Whether the filesystem is mounted read-only (1: read-only, 0: read-write)",
+			[]string{"host", "device", "mount_point", "fstype"},
+			nil,
+		),
+	}
+}
+
+// Decode 解析系统统计监控配置（布尔值）
+func (m *statMonitor) Decode(node *yaml.Node) (any, error) {
+	if node.Kind == yaml.MappingNode {
+		var spec statSpec
+		if err := node.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode stat monitor: %w", err)
+		}
+		return spec, nil
+	}
+
+	var enabled bool
+	if err := node.Decode(&enabled); err != nil {
+		return nil, fmt.Errorf("failed to decode stat monitor: %w", err)
 	}
+	if !enabled {
+		return nil, nil
+	}
+	return statSpec{}, nil
+}
+
+// Describe 实现Monitor接口
+func (m *statMonitor) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuSeconds
+	ch <- m.cpuGuestSeconds
+	ch <- m.cpuUserSeconds
+	ch <- m.cpuSystemSeconds
+	ch <- m.cpuIdleSeconds
+	ch <- m.cpuIowaitSeconds
+	ch <- m.cpuUsagePercent
+	ch <- m.contextSwitches
+	ch <- m.interrupts
+	ch <- m.processesRunning
+	ch <- m.processesBlocked
+	ch <- m.load1
+	ch <- m.load5
+	ch <- m.load15
+	ch <- m.runnableTasks
+	ch <- m.tasks
+	ch <- m.uptime
+	ch <- m.bootTime
+	ch <- m.reboots
+	ch <- m.memoryTotalBytes
+	ch <- m.memoryFreeBytes
+	ch <- m.memoryAvailableBytes
+	ch <- m.memoryBuffersBytes
+	ch <- m.memoryCachedBytes
+	ch <- m.memoryUsagePercent
+	ch <- m.diskTotalBytes
+	ch <- m.diskUsedBytes
+	ch <- m.diskFreeBytes
+	ch <- m.diskUsagePercent
+	ch <- m.diskInodes
+	ch <- m.diskInodesUsed
+	ch <- m.diskInodesFree
+	ch <- m.diskReadOnly
+}
+
+// Collect 实现Monitor接口
+func (m *statMonitor) Collect(ctx context.Context, exec transport.Executor, host string, spec any, ch chan<- prometheus.Metric) error {
+	return m.collectStatMetrics(ctx, exec, host, spec.(statSpec), ch)
+}
+
+// 系统统计使用的命令，df的 -B1 表示以字节为单位显示
+const (
+	cpuStatCommand = "cat /proc/stat"
+	meminfoCommand = "cat /proc/meminfo"
+	loadavgCommand = "cat /proc/loadavg"
+	uptimeCommand  = "cat /proc/uptime"
+	dfCommand      = "df -B1 -x tmpfs -x devtmpfs -x squashfs 2>/dev/null"
+	dfInodeCommand = "df -i -x tmpfs -x devtmpfs -x squashfs 2>/dev/null"
+	mountsCommand  = "cat /proc/mounts"
+)
+
+// Commands 实现CommandLister接口，首次采集时第二次读取 /proc/stat 需要在间隔之后执行，不参与批量执行
+func (m *statMonitor) Commands(spec any) []string {
+	return []string{cpuStatCommand, loadavgCommand, uptimeCommand, meminfoCommand, dfCommand, dfInodeCommand, mountsCommand}
+}
 
-	stats1 := parseCPUStats(output1)
-	if stats1 == nil {
-		logger.Printf("Failed to parse CPU stats on %s", host)
-		return
+// collectStatMetrics 收集系统统计指标 (CPU、内存、磁盘)
+func (m *statMonitor) collectStatMetrics(ctx context.Context, exec transport.Executor, host string, spec statSpec, ch chan<- prometheus.Metric) error {
+	// 各部分互不影响，任一部分失败都会被报告
+	return errors.Join(
+		// 收集CPU指标
+		m.collectCPUMetrics(ctx, exec, host, spec, ch),
+		// 收集负载和运行时间指标
+		m.collectLoadMetrics(ctx, exec, host, ch),
+		// 收集内存指标
+		m.collectMemoryMetrics(ctx, exec, host, ch),
+		// 收集磁盘指标
+		m.collectDiskMetrics(ctx, exec, host, ch),
+		// 收集inode和挂载状态指标
+		m.collectFilesystemMetrics(ctx, exec, host, ch),
+	)
+}
+
+// collectCPUMetrics 收集CPU指标
+// CPU使用率根据上一次采集保存的统计计算；首次采集或计数器被重置（例如重启）时间隔1秒采样两次
+func (m *statMonitor) collectCPUMetrics(ctx context.Context, exec transport.Executor, host string, spec statSpec, ch chan<- prometheus.Metric) error {
+	stats, err := readCPUStats(ctx, exec)
+	if err != nil {
+		return err
 	}
 
 	// 发送CPU累计时间指标
 	ch <- prometheus.MustNewConstMetric(
-		c.cpuUserSeconds,
+		m.cpuUserSeconds,
 		prometheus.CounterValue,
-		stats1.User,
+		stats.User,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.cpuSystemSeconds,
+		m.cpuSystemSeconds,
 		prometheus.CounterValue,
-		stats1.System,
+		stats.System,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.cpuIdleSeconds,
+		m.cpuIdleSeconds,
 		prometheus.CounterValue,
-		stats1.Idle,
+		stats.Idle,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.cpuIowaitSeconds,
+		m.cpuIowaitSeconds,
 		prometheus.CounterValue,
-		stats1.Iowait,
+		stats.Iowait,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.contextSwitches,
+		m.contextSwitches,
 		prometheus.CounterValue,
-		stats1.Ctxt,
+		stats.Ctxt,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.interrupts,
+		m.interrupts,
 		prometheus.CounterValue,
-		stats1.Intr,
+		stats.Intr,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.processesRunning,
+		m.processesRunning,
 		prometheus.GaugeValue,
-		stats1.ProcsRunning,
+		stats.ProcsRunning,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.processesBlocked,
+		m.processesBlocked,
 		prometheus.GaugeValue,
-		stats1.ProcsBlocked,
+		stats.ProcsBlocked,
 		host,
 	)
 
-	// 计算CPU使用率：等待1秒后再次采样
-	time.Sleep(1 * time.Second)
+	// 发送启动时间以及观察到的重启次数
+	if stats.BootTime > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			m.bootTime,
+			prometheus.GaugeValue,
+			stats.BootTime,
+			host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			m.reboots,
+			prometheus.CounterValue,
+			m.observeBootTime(host, stats.BootTime),
+			host,
+		)
+	}
 
-	output2, err := client.ExecuteCommand("cat /proc/stat")
-	if err != nil {
-		logger.Printf("Failed to read /proc/stat (2nd time) on %s: %v", host, err)
-		// 即使第二次读取失败，也不影响其他指标
+	// 发送各模式的CPU时间
+	if spec.PerCPU {
+		for cpu, times := range stats.PerCPU {
+			m.collectCPUTimes(host, cpu, times, ch)
+		}
 	} else {
-		stats2 := parseCPUStats(output2)
-		if stats2 != nil {
-			// 计算差值
-			totalDelta := stats2.Total - stats1.Total
-			idleDelta := stats2.Idle - stats1.Idle
-
-			if totalDelta > 0 {
-				// CPU使用率 = 1 - idle增量 / total增量 (范围: 0-1)
-				cpuUsage := 1.0 - idleDelta/totalDelta
-
-				// 边界检查：确保使用率在 0-1 之间
-				if cpuUsage < 0 {
-					logger.Printf("Warning: CPU usage calculated as %.4f (negative), setting to 0", cpuUsage)
-					cpuUsage = 0
-				} else if cpuUsage > 1 {
-					logger.Printf("Warning: CPU usage calculated as %.4f (>1), clamping to 1", cpuUsage)
-					cpuUsage = 1
-				}
+		m.collectCPUTimes(host, "all", stats.Times, ch)
+	}
 
-				ch <- prometheus.MustNewConstMetric(
-					c.cpuUsagePercent,
-					prometheus.GaugeValue,
-					cpuUsage,
-					host,
-				)
-
-				logger.Printf("Collected CPU metrics for host %s (usage: %.4f, total_delta: %.2f, idle_delta: %.2f)",
-					host, cpuUsage, totalDelta, idleDelta)
-			} else {
-				logger.Printf("Collected CPU metrics for host %s (unable to calculate usage: total_delta=%.2f)", host, totalDelta)
-			}
+	prev := m.swapCPUSample(host, stats)
+	if prev == nil || cpuCountersReset(prev, stats) {
+		// 没有可用的上一次统计：等待1秒后再次采样
+		select {
+		case <-time.After(1 * time.Second):
+		case <-ctx.Done():
+			return execError(cpuStatCommand, ctx.Err())
 		}
+
+		next, err := readCPUStats(ctx, exec)
+		if err != nil {
+			return err
+		}
+		m.swapCPUSample(host, next)
+		prev, stats = stats, next
+	}
+
+	// 计算差值
+	totalDelta := stats.Total - prev.Total
+	idleDelta := stats.Idle - prev.Idle
+	if totalDelta <= 0 {
+		logger.Printf("Collected CPU metrics for host %s (unable to calculate usage: total_delta=%.2f)", host, totalDelta)
+		return nil
+	}
+
+	// CPU使用率 = 1 - idle增量 / total增量 (范围: 0-1)
+	cpuUsage := 1.0 - idleDelta/totalDelta
+
+	// 边界检查：确保使用率在 0-1 之间
+	if cpuUsage < 0 {
+		logger.Printf("Warning: CPU usage calculated as %.4f (negative), setting to 0", cpuUsage)
+		cpuUsage = 0
+	} else if cpuUsage > 1 {
+		logger.Printf("Warning: CPU usage calculated as %.4f (>1), clamping to 1", cpuUsage)
+		cpuUsage = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		m.cpuUsagePercent,
+		prometheus.GaugeValue,
+		cpuUsage,
+		host,
+	)
+
+	logger.Printf("Collected CPU metrics for host %s (usage: %.4f, total_delta: %.2f, idle_delta: %.2f)",
+		host, cpuUsage, totalDelta, idleDelta)
+	return nil
+}
+
+// collectCPUTimes 发送一行cpu统计的 cpu_seconds_total 和 cpu_guest_seconds_total
+// 与node_exporter一致，user和nice包含guest时间
+func (m *statMonitor) collectCPUTimes(host, cpu string, times CPUTimes, ch chan<- prometheus.Metric) {
+	modes := []struct {
+		name  string
+		value float64
+	}{
+		{"user", times.User},
+		{"nice", times.Nice},
+		{"system", times.System},
+		{"idle", times.Idle},
+		{"iowait", times.Iowait},
+		{"irq", times.Irq},
+		{"softirq", times.Softirq},
+		{"steal", times.Steal},
+	}
+	for _, mode := range modes {
+		ch <- prometheus.MustNewConstMetric(
+			m.cpuSeconds,
+			prometheus.CounterValue,
+			mode.value,
+			host, cpu, mode.name,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		m.cpuGuestSeconds,
+		prometheus.CounterValue,
+		times.Guest,
+		host, cpu, "user",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		m.cpuGuestSeconds,
+		prometheus.CounterValue,
+		times.GuestNice,
+		host, cpu, "nice",
+	)
+}
+
+// readCPUStats 读取并解析 /proc/stat
+func readCPUStats(ctx context.Context, exec transport.Executor) (*CPUStats, error) {
+	output, err := runCommand(ctx, exec, cpuStatCommand)
+	if err != nil {
+		return nil, err
+	}
+	stats := parseCPUStats(output, FactsFromContext(ctx).ClockTicks)
+	if stats == nil {
+		return nil, parseError("failed to parse CPU stats")
+	}
+	return stats, nil
+}
+
+// statHostMaxAge 超过该时间未采集的主机状态从缓存中删除，其中的CPU统计也不再用于计算使用率
+const statHostMaxAge = 10 * time.Minute
+
+// bootTimeTolerance btime由内核根据当前时间和运行时间计算，校准时钟时会有秒级抖动，变化超过该值才算重启
+const bootTimeTolerance = 5.0
+
+// statHost 某个主机在两次采集之间保存的状态
+type statHost struct {
+	cpu      *CPUStats // 最近一次读取的CPU统计
+	cpuTime  time.Time
+	bootTime float64 // 最近一次读取的启动时间
+	reboots  float64 // 观察到的重启次数
+	lastSeen time.Time
+}
+
+// hostState 返回host的状态，不存在时创建，调用方需持有m.mu
+// 同时清理不再采集的主机（例如 /probe 的临时目标）
+func (m *statMonitor) hostState(host string, now time.Time) *statHost {
+	for h, state := range m.hosts {
+		if now.Sub(state.lastSeen) > statHostMaxAge {
+			delete(m.hosts, h)
+		}
+	}
+	state, ok := m.hosts[host]
+	if !ok {
+		state = &statHost{}
+		m.hosts[host] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+// swapCPUSample 保存host本次读取的CPU统计，返回上一次的统计（没有或已过期时返回nil）
+func (m *statMonitor) swapCPUSample(host string, stats *CPUStats) *CPUStats {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.hostState(host, now)
+	prev, prevTime := state.cpu, state.cpuTime
+	state.cpu, state.cpuTime = stats, now
+
+	if prev == nil || now.Sub(prevTime) > statHostMaxAge {
+		return nil
+	}
+	return prev
+}
+
+// observeBootTime 保存host本次读取的启动时间，返回启动时间变化（主机重启）的累计次数
+func (m *statMonitor) observeBootTime(host string, bootTime float64) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.hostState(host, time.Now())
+	if state.bootTime != 0 && math.Abs(bootTime-state.bootTime) > bootTimeTolerance {
+		state.reboots++
+		logger.Printf("Boot time of %s changed from %.0f to %.0f, counting a reboot", host, state.bootTime, bootTime)
 	}
+	state.bootTime = bootTime
+	return state.reboots
 }
 
-// parseCPUStats 解析 /proc/stat 输出
-func parseCPUStats(output string) *CPUStats {
-	stats := &CPUStats{}
+// cpuCountersReset 判断两次统计之间计数器是否没有增长或被重置（例如主机重启）
+func cpuCountersReset(prev, cur *CPUStats) bool {
+	return cur.Total <= prev.Total || cur.Idle < prev.Idle
+}
+
+// parseCPUStats 解析 /proc/stat 输出，clockTicks为主机的USER_HZ
+// 未找到汇总的cpu行时返回nil
+func parseCPUStats(output string, clockTicks float64) *CPUStats {
+	stats := &CPUStats{PerCPU: make(map[string]CPUTimes)}
+	found := false
 	lines := strings.Split(output, "\n")
 
 	for _, line := range lines {
@@ -176,27 +706,16 @@ func parseCPUStats(output string) *CPUStats {
 
 		switch fields[0] {
 		case "cpu":
-			// cpu line: cpu user nice system idle iowait irq softirq steal guest guest_nice
-			// Total = user + nice + system + idle + iowait + irq + softirq + steal
+			// 汇总行，Total = user + nice + system + idle + iowait + irq + softirq + steal
 			// 注意: guest和guest_nice已经包含在user和nice中，不需要再加
-			if len(fields) >= 9 {
-				// 时间单位是 USER_HZ (通常是1/100秒), 转换为秒
-				user, _ := strconv.ParseFloat(fields[1], 64)
-				nice, _ := strconv.ParseFloat(fields[2], 64)
-				system, _ := strconv.ParseFloat(fields[3], 64)
-				idle, _ := strconv.ParseFloat(fields[4], 64)
-				iowait, _ := strconv.ParseFloat(fields[5], 64)
-				irq, _ := strconv.ParseFloat(fields[6], 64)
-				softirq, _ := strconv.ParseFloat(fields[7], 64)
-				steal, _ := strconv.ParseFloat(fields[8], 64)
-
-				stats.User = user / 100.0
-				stats.System = system / 100.0
-				stats.Idle = idle / 100.0
-				stats.Iowait = iowait / 100.0
-
-				// Total = user + nice + system + idle + iowait + irq + softirq + steal
-				stats.Total = (user + nice + system + idle + iowait + irq + softirq + steal) / 100.0
+			if times, ok := parseCPUTimes(fields, clockTicks); ok {
+				stats.Times = times
+				stats.User = times.User
+				stats.System = times.System
+				stats.Idle = times.Idle
+				stats.Iowait = times.Iowait
+				stats.Total = times.User + times.Nice + times.System + times.Idle + times.Iowait + times.Irq + times.Softirq + times.Steal
+				found = true
 			}
 		case "ctxt":
 			// 上下文切换
@@ -218,66 +737,177 @@ func parseCPUStats(output string) *CPUStats {
 			if len(fields) >= 2 {
 				stats.ProcsBlocked, _ = strconv.ParseFloat(fields[1], 64)
 			}
+		case "btime":
+			// 启动时间
+			if len(fields) >= 2 {
+				stats.BootTime, _ = strconv.ParseFloat(fields[1], 64)
+			}
+		default:
+			// 各CPU核心: cpu0, cpu1, ...
+			if cpu, ok := strings.CutPrefix(fields[0], "cpu"); ok {
+				if times, ok := parseCPUTimes(fields, clockTicks); ok {
+					stats.PerCPU[cpu] = times
+				}
+			}
 		}
 	}
 
+	if !found {
+		return nil
+	}
 	return stats
 }
 
+// parseCPUTimes 解析一行cpu统计
+// cpu user nice system idle iowait irq softirq steal [guest guest_nice]，旧内核没有guest字段
+func parseCPUTimes(fields []string, clockTicks float64) (CPUTimes, bool) {
+	if len(fields) < 9 {
+		return CPUTimes{}, false
+	}
+	values := make([]float64, 10)
+	for i := 1; i < len(fields) && i <= len(values); i++ {
+		// 时间单位是 1/USER_HZ 秒 (USER_HZ通常是100), 转换为秒
+		v, _ := strconv.ParseFloat(fields[i], 64)
+		values[i-1] = v / clockTicks
+	}
+	return CPUTimes{
+		User:      values[0],
+		Nice:      values[1],
+		System:    values[2],
+		Idle:      values[3],
+		Iowait:    values[4],
+		Irq:       values[5],
+		Softirq:   values[6],
+		Steal:     values[7],
+		Guest:     values[8],
+		GuestNice: values[9],
+	}, true
+}
+
+// collectLoadMetrics 收集负载和运行时间指标
+func (m *statMonitor) collectLoadMetrics(ctx context.Context, exec transport.Executor, host string, ch chan<- prometheus.Metric) error {
+	var errs []error
+
+	output, err := runCommand(ctx, exec, loadavgCommand)
+	if err == nil {
+		err = m.collectLoadavg(output, host, ch)
+	}
+	errs = append(errs, err)
+
+	output, err = runCommand(ctx, exec, uptimeCommand)
+	if err == nil {
+		err = m.collectUptime(output, host, ch)
+	}
+	errs = append(errs, err)
+
+	return errors.Join(errs...)
+}
+
+// collectLoadavg 解析并发送 /proc/loadavg
+// 格式: load1 load5 load15 可运行任务数/任务总数 最近的PID
+func (m *statMonitor) collectLoadavg(output, host string, ch chan<- prometheus.Metric) error {
+	fields := strings.Fields(output)
+	if len(fields) < 4 {
+		return parseError("failed to parse /proc/loadavg: %q", strings.TrimSpace(output))
+	}
+	runnable, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return parseError("failed to parse /proc/loadavg: %q", strings.TrimSpace(output))
+	}
+
+	values := []struct {
+		desc  *prometheus.Desc
+		value string
+	}{
+		{m.load1, fields[0]},
+		{m.load5, fields[1]},
+		{m.load15, fields[2]},
+		{m.runnableTasks, runnable},
+		{m.tasks, total},
+	}
+	for _, v := range values {
+		value, err := strconv.ParseFloat(v.value, 64)
+		if err != nil {
+			return parseError("failed to parse /proc/loadavg: %q", strings.TrimSpace(output))
+		}
+		ch <- prometheus.MustNewConstMetric(v.desc, prometheus.GaugeValue, value, host)
+	}
+	return nil
+}
+
+// collectUptime 解析并发送 /proc/uptime
+// 格式: 运行时间 空闲时间（秒）
+func (m *statMonitor) collectUptime(output, host string, ch chan<- prometheus.Metric) error {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return parseError("failed to parse /proc/uptime: %q", strings.TrimSpace(output))
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return parseError("failed to parse /proc/uptime: %q", strings.TrimSpace(output))
+	}
+	ch <- prometheus.MustNewConstMetric(
+		m.uptime,
+		prometheus.GaugeValue,
+		uptime,
+		host,
+	)
+	return nil
+}
+
 // collectMemoryMetrics 收集内存指标
-func (c *SSHCollector) collectMemoryMetrics(client *sshclient.Client, host string, ch chan<- prometheus.Metric) {
+func (m *statMonitor) collectMemoryMetrics(ctx context.Context, exec transport.Executor, host string, ch chan<- prometheus.Metric) error {
 	// 读取 /proc/meminfo
-	output, err := client.ExecuteCommand("cat /proc/meminfo")
+	output, err := runCommand(ctx, exec, meminfoCommand)
 	if err != nil {
-		logger.Printf("Failed to read /proc/meminfo on %s: %v", host, err)
-		return
+		return err
 	}
 
 	stats := parseMemoryStats(output)
 	if stats == nil {
-		logger.Printf("Failed to parse memory stats on %s", host)
-		return
+		return parseError("failed to parse memory stats")
 	}
 
 	// 发送内存指标
 	ch <- prometheus.MustNewConstMetric(
-		c.memoryTotalBytes,
+		m.memoryTotalBytes,
 		prometheus.GaugeValue,
 		stats.Total,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.memoryFreeBytes,
+		m.memoryFreeBytes,
 		prometheus.GaugeValue,
 		stats.Free,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.memoryAvailableBytes,
+		m.memoryAvailableBytes,
 		prometheus.GaugeValue,
 		stats.Available,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.memoryBuffersBytes,
+		m.memoryBuffersBytes,
 		prometheus.GaugeValue,
 		stats.Buffers,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.memoryCachedBytes,
+		m.memoryCachedBytes,
 		prometheus.GaugeValue,
 		stats.Cached,
 		host,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		c.memoryUsagePercent,
+		m.memoryUsagePercent,
 		prometheus.GaugeValue,
 		stats.UsagePercent,
 		host,
 	)
 
 	logger.Printf("Collected memory metrics for host %s", host)
+	return nil
 }
 
 // parseMemoryStats 解析 /proc/meminfo 输出
@@ -315,6 +945,11 @@ func parseMemoryStats(output string) *MemoryStats {
 		}
 	}
 
+	// 没有MemTotal说明输出不是有效的 /proc/meminfo
+	if stats.Total == 0 {
+		return nil
+	}
+
 	// 计算使用率
 	if stats.Total > 0 {
 		used := stats.Total - stats.Available
@@ -325,13 +960,11 @@ func parseMemoryStats(output string) *MemoryStats {
 }
 
 // collectDiskMetrics 收集磁盘指标
-func (c *SSHCollector) collectDiskMetrics(client *sshclient.Client, host string, ch chan<- prometheus.Metric) {
+func (m *statMonitor) collectDiskMetrics(ctx context.Context, exec transport.Executor, host string, ch chan<- prometheus.Metric) error {
 	// 执行 df 命令获取磁盘使用情况
-	// -B1 表示以字节为单位显示
-	output, err := client.ExecuteCommand("df -B1 -x tmpfs -x devtmpfs -x squashfs 2>/dev/null")
+	output, err := runCommand(ctx, exec, dfCommand)
 	if err != nil {
-		logger.Printf("Failed to get disk usage on %s: %v", host, err)
-		return
+		return err
 	}
 
 	diskStats := parseDiskStats(output)
@@ -339,30 +972,31 @@ func (c *SSHCollector) collectDiskMetrics(client *sshclient.Client, host string,
 
 	for _, disk := range diskStats {
 		ch <- prometheus.MustNewConstMetric(
-			c.diskTotalBytes,
+			m.diskTotalBytes,
 			prometheus.GaugeValue,
 			disk.Total,
 			host, disk.Device, disk.MountPoint,
 		)
 		ch <- prometheus.MustNewConstMetric(
-			c.diskUsedBytes,
+			m.diskUsedBytes,
 			prometheus.GaugeValue,
 			disk.Used,
 			host, disk.Device, disk.MountPoint,
 		)
 		ch <- prometheus.MustNewConstMetric(
-			c.diskFreeBytes,
+			m.diskFreeBytes,
 			prometheus.GaugeValue,
 			disk.Free,
 			host, disk.Device, disk.MountPoint,
 		)
 		ch <- prometheus.MustNewConstMetric(
-			c.diskUsagePercent,
+			m.diskUsagePercent,
 			prometheus.GaugeValue,
 			disk.UsagePercent,
 			host, disk.Device, disk.MountPoint,
 		)
 	}
+	return nil
 }
 
 // parseDiskStats 解析 df 命令输出
@@ -406,3 +1040,136 @@ func parseDiskStats(output string) []DiskStats {
 
 	return diskStats
 }
+
+// collectFilesystemMetrics 收集文件系统的inode使用情况和只读状态
+// 文件系统类型和挂载选项来自 /proc/mounts，读取失败时仍输出inode指标（fstype为空）
+func (m *statMonitor) collectFilesystemMetrics(ctx context.Context, exec transport.Executor, host string, ch chan<- prometheus.Metric) error {
+	output, err := runCommand(ctx, exec, dfInodeCommand)
+	if err != nil {
+		return err
+	}
+	inodeStats := parseInodeStats(output)
+
+	mounts := make(map[string]MountInfo)
+	mountsOutput, mountsErr := runCommand(ctx, exec, mountsCommand)
+	if mountsErr == nil {
+		mounts = parseMounts(mountsOutput)
+	}
+
+	for _, inodes := range inodeStats {
+		mount := mounts[inodes.MountPoint]
+		ch <- prometheus.MustNewConstMetric(
+			m.diskInodes,
+			prometheus.GaugeValue,
+			inodes.Total,
+			host, inodes.Device, inodes.MountPoint, mount.FSType,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			m.diskInodesUsed,
+			prometheus.GaugeValue,
+			inodes.Used,
+			host, inodes.Device, inodes.MountPoint, mount.FSType,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			m.diskInodesFree,
+			prometheus.GaugeValue,
+			inodes.Free,
+			host, inodes.Device, inodes.MountPoint, mount.FSType,
+		)
+		if mountsErr != nil {
+			continue
+		}
+		readOnly := 0.0
+		if mount.ReadOnly {
+			readOnly = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			m.diskReadOnly,
+			prometheus.GaugeValue,
+			readOnly,
+			host, inodes.Device, inodes.MountPoint, mount.FSType,
+		)
+	}
+	return mountsErr
+}
+
+// parseInodeStats 解析 df -i 命令输出
+func parseInodeStats(output string) []InodeStats {
+	var inodeStats []InodeStats
+	lines := strings.Split(output, "\n")
+
+	// 跳过第一行(表头)
+	for i, line := range lines {
+		if i == 0 || line == "" {
+			continue
+		}
+
+		// df -i 输出格式: Filesystem Inodes IUsed IFree IUse% Mounted on
+		// 不支持inode的文件系统（例如vfat）IUse%为"-"，各计数为0
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		total, err1 := strconv.ParseFloat(fields[1], 64)
+		used, err2 := strconv.ParseFloat(fields[2], 64)
+		free, err3 := strconv.ParseFloat(fields[3], 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+
+		inodeStats = append(inodeStats, InodeStats{
+			Device:     fields[0],
+			MountPoint: strings.Join(fields[5:], " "),
+			Total:      total,
+			Used:       used,
+			Free:       free,
+		})
+	}
+
+	return inodeStats
+}
+
+// parseMounts 解析 /proc/mounts，返回以挂载点为键的挂载信息
+// 同一挂载点被多次挂载时以最后一次（可见的）为准
+func parseMounts(output string) map[string]MountInfo {
+	mounts := make(map[string]MountInfo)
+	for _, line := range strings.Split(output, "\n") {
+		// 格式: 设备 挂载点 类型 选项 dump pass，字段中的空格等字符被转义为 \040 形式
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		mount := MountInfo{
+			Device:     unescapeMountField(fields[0]),
+			MountPoint: unescapeMountField(fields[1]),
+			FSType:     fields[2],
+		}
+		for _, option := range strings.Split(fields[3], ",") {
+			if option == "ro" {
+				mount.ReadOnly = true
+				break
+			}
+		}
+		mounts[mount.MountPoint] = mount
+	}
+	return mounts
+}
+
+// unescapeMountField 还原 /proc/mounts 中八进制转义的字符（例如 \040 为空格）
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, "\\") {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if v, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
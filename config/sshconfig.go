@@ -0,0 +1,273 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sshConfigFile 解析后的OpenSSH客户端配置文件（只支持Host块，Match块被忽略）
+type sshConfigFile struct {
+	blocks []sshConfigBlock
+}
+
+// sshConfigBlock 配置文件中的一个Host块，文件开头不属于任何Host块的选项对所有主机生效
+type sshConfigBlock struct {
+	patterns []string
+	match    bool // Match块，不支持，始终不匹配
+	options  []sshConfigOption
+}
+
+// sshConfigOption 配置项，key为小写
+type sshConfigOption struct {
+	key   string
+	value string
+}
+
+// maxSSHConfigIncludeDepth Include的最大嵌套层数
+const maxSSHConfigIncludeDepth = 16
+
+// loadSSHConfigFile 读取OpenSSH配置文件（支持Include）
+func loadSSHConfigFile(file string) (*sshConfigFile, error) {
+	f := &sshConfigFile{blocks: []sshConfigBlock{{patterns: []string{"*"}}}}
+	if err := f.parse(expandHome(file), 0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parse 解析文件内容并追加到f中
+func (f *sshConfigFile) parse(file string, depth int) error {
+	if depth > maxSSHConfigIncludeDepth {
+		return fmt.Errorf("too many nested includes in %s", file)
+	}
+	data, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to read ssh_config: %w", err)
+	}
+	defer data.Close()
+
+	scanner := bufio.NewScanner(data)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value := splitSSHConfigLine(line)
+		if value == "" {
+			return fmt.Errorf("%s:%d: missing value for %s", file, lineNo, key)
+		}
+		switch key {
+		case "host":
+			f.blocks = append(f.blocks, sshConfigBlock{patterns: strings.Fields(value)})
+		case "match":
+			f.blocks = append(f.blocks, sshConfigBlock{match: true})
+		case "include":
+			for _, pattern := range strings.Fields(value) {
+				pattern = expandHome(pattern)
+				if !filepath.IsAbs(pattern) {
+					// 相对路径相对于用户配置目录 ~/.ssh
+					pattern = filepath.Join(expandHome("~/.ssh"), pattern)
+				}
+				matches, err := filepath.Glob(pattern)
+				if err != nil {
+					return fmt.Errorf("%s:%d: invalid include %q: %w", file, lineNo, pattern, err)
+				}
+				for _, match := range matches {
+					if err := f.parse(match, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			block := &f.blocks[len(f.blocks)-1]
+			block.options = append(block.options, sshConfigOption{key: key, value: value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ssh_config: %w", err)
+	}
+	return nil
+}
+
+// splitSSHConfigLine 将一行拆分为小写的关键字和值，支持 "Key Value" 和 "Key=Value" 两种写法
+func splitSSHConfigLine(line string) (string, string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return strings.ToLower(line), ""
+	}
+	key := strings.ToLower(line[:i])
+	value := strings.TrimSpace(line[i:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+	return key, value
+}
+
+// matches 判断Host块是否匹配alias，以!开头的模式匹配时整个块不匹配
+func (b *sshConfigBlock) matches(alias string) bool {
+	if b.match {
+		return false
+	}
+	matched := false
+	for _, pattern := range b.patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), alias)
+		if ok && negate {
+			return false
+		}
+		if ok {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// lookup 返回alias匹配的配置项，与OpenSSH一样每个关键字以第一次出现的值为准（IdentityFile可以有多个）
+func (f *sshConfigFile) lookup(alias string) map[string][]string {
+	values := make(map[string][]string)
+	for i := range f.blocks {
+		block := &f.blocks[i]
+		if !block.matches(alias) {
+			continue
+		}
+		for _, opt := range block.options {
+			if _, ok := values[opt.key]; ok && opt.key != "identityfile" {
+				continue
+			}
+			values[opt.key] = append(values[opt.key], opt.value)
+		}
+	}
+	return values
+}
+
+// sshConfigHost 从OpenSSH配置中解析出的主机连接参数
+type sshConfigHost struct {
+	HostName      string
+	User          string
+	Port          int
+	IdentityFiles []string
+	ProxyJump     string
+	HostKeyAlias  string
+}
+
+// resolve 解析alias对应的连接参数并展开其中的 ~ 和 %h、%n、%r、%u、%d、%p、%% 等记号
+func (f *sshConfigFile) resolve(alias string) (sshConfigHost, error) {
+	values := f.lookup(alias)
+	first := func(key string) string {
+		if v := values[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	h := sshConfigHost{
+		HostName:     first("hostname"),
+		User:         first("user"),
+		ProxyJump:    first("proxyjump"),
+		HostKeyAlias: first("hostkeyalias"),
+	}
+	if port := first("port"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return h, fmt.Errorf("invalid Port %q for %s", port, alias)
+		}
+		h.Port = p
+	}
+	if h.HostName == "" {
+		h.HostName = alias
+	}
+	h.HostName = expandSSHTokens(h.HostName, alias, alias, h)
+
+	for _, identity := range values["identityfile"] {
+		if strings.EqualFold(identity, "none") {
+			continue
+		}
+		h.IdentityFiles = append(h.IdentityFiles, expandHome(expandSSHTokens(identity, alias, h.HostName, h)))
+	}
+	return h, nil
+}
+
+// expandSSHTokens 展开OpenSSH配置中的百分号记号
+func expandSSHTokens(value, alias, hostname string, h sshConfigHost) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+	localUser := ""
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+	port := "22"
+	if h.Port > 0 {
+		port = strconv.Itoa(h.Port)
+	}
+	remoteUser := h.User
+	if remoteUser == "" {
+		remoteUser = localUser
+	}
+	home, _ := os.UserHomeDir()
+
+	replacer := strings.NewReplacer(
+		"%%", "%",
+		"%h", hostname,
+		"%n", alias,
+		"%p", port,
+		"%r", remoteUser,
+		"%u", localUser,
+		"%d", home,
+	)
+	return replacer.Replace(value)
+}
+
+// parseProxyJump 解析ProxyJump的值（[user@]host[:port]，多个跳板机以逗号分隔）
+func parseProxyJump(value string) ([]SSHConfig, error) {
+	if strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	var hops []SSHConfig
+	for _, hop := range strings.Split(value, ",") {
+		hop = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(hop), "ssh://"))
+		var sc SSHConfig
+		if i := strings.LastIndex(hop, "@"); i >= 0 {
+			sc.User = hop[:i]
+			hop = hop[i+1:]
+		}
+		if host, port, err := net.SplitHostPort(hop); err == nil {
+			p, err := strconv.Atoi(port)
+			if err != nil || p <= 0 || p > 65535 {
+				return nil, fmt.Errorf("invalid port in ProxyJump %q", value)
+			}
+			sc.Host = host
+			sc.Port = p
+		} else {
+			sc.Host = hop
+		}
+		if sc.Host == "" {
+			return nil, fmt.Errorf("invalid ProxyJump %q", value)
+		}
+		hops = append(hops, sc)
+	}
+	return hops, nil
+}
+
+// expandHome 将路径开头的 ~ 展开为用户主目录
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSSHConfig 将content写入dir下的name文件，返回文件路径
+func writeSSHConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestSSHConfigResolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	localUser := ""
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+
+	// 相对路径的Include相对于 ~/.ssh
+	writeSSHConfig(t, home, ".ssh/conf.d/db.conf", `
+Host db
+    HostName db.internal
+    User dbuser
+`)
+	file := writeSSHConfig(t, home, ".ssh/config", `
+# comment
+Include conf.d/*.conf
+
+# Match块被忽略，否则web-1的User为matched
+Match host web-1
+    User matched
+
+Host web-* !web-internal
+    HostName %h.example.com
+    Port=2200
+    User webuser
+
+Host web-1
+    HostName ignored.example.com
+    IdentityFile ~/.ssh/web1
+
+Host tokens
+    HostName tokens-%n
+    IdentityFile %d/keys/%u-%r-%p-%%
+    IdentityFile none
+
+Host quoted
+    HostName = "quoted.example.com"
+    ProxyJump jump@bastion:2222,second
+
+Host *
+    IdentityFile ~/.ssh/id_%r@%h
+    User fallback
+`)
+	f, err := loadSSHConfigFile(file)
+	if err != nil {
+		t.Fatalf("loadSSHConfigFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		alias string
+		want  sshConfigHost
+	}{
+		{
+			name:  "first value wins",
+			alias: "web-1",
+			want: sshConfigHost{
+				HostName: "web-1.example.com",
+				User:     "webuser",
+				Port:     2200,
+				IdentityFiles: []string{
+					filepath.Join(home, ".ssh/web1"),
+					filepath.Join(home, ".ssh/id_webuser@web-1.example.com"),
+				},
+			},
+		},
+		{
+			name:  "negated pattern",
+			alias: "web-internal",
+			want: sshConfigHost{
+				HostName:      "web-internal",
+				User:          "fallback",
+				IdentityFiles: []string{filepath.Join(home, ".ssh/id_fallback@web-internal")},
+			},
+		},
+		{
+			name:  "include",
+			alias: "db",
+			want: sshConfigHost{
+				HostName:      "db.internal",
+				User:          "dbuser",
+				IdentityFiles: []string{filepath.Join(home, ".ssh/id_dbuser@db.internal")},
+			},
+		},
+		{
+			name:  "tokens",
+			alias: "tokens",
+			want: sshConfigHost{
+				HostName: "tokens-tokens",
+				User:     "fallback",
+				IdentityFiles: []string{
+					home + "/keys/" + localUser + "-fallback-22-%",
+					filepath.Join(home, ".ssh/id_fallback@tokens-tokens"),
+				},
+			},
+		},
+		{
+			name:  "key=value and quotes",
+			alias: "quoted",
+			want: sshConfigHost{
+				HostName:      "quoted.example.com",
+				User:          "fallback",
+				ProxyJump:     "jump@bastion:2222,second",
+				IdentityFiles: []string{filepath.Join(home, ".ssh/id_fallback@quoted.example.com")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.resolve(tt.alias)
+			if err != nil {
+				t.Fatalf("resolve(%q) error = %v", tt.alias, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve(%q) = %+v, want %+v", tt.alias, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSHConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		alias   string
+		wantErr string
+	}{
+		{name: "missing value", content: "Host a\n    HostName\n", wantErr: "missing value for hostname"},
+		{name: "invalid port", content: "Host a\n    Port ssh\n", alias: "a", wantErr: `invalid Port "ssh"`},
+		{name: "port out of range", content: "Host a\n    Port 70000\n", alias: "a", wantErr: `invalid Port "70000"`},
+		{name: "include loop", content: "Include {{file}}\n", wantErr: "too many nested includes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config")
+			writeSSHConfig(t, filepath.Dir(file), "config", strings.ReplaceAll(tt.content, "{{file}}", file))
+
+			f, err := loadSSHConfigFile(file)
+			if err == nil && tt.alias != "" {
+				_, err = f.resolve(tt.alias)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []SSHConfig
+		wantErr bool
+	}{
+		{name: "none", value: "none"},
+		{name: "host", value: "bastion", want: []SSHConfig{{Host: "bastion"}}},
+		{
+			name:  "user and port",
+			value: "jump@bastion:2222",
+			want:  []SSHConfig{{Host: "bastion", User: "jump", Port: 2222}},
+		},
+		{
+			name:  "several hops",
+			value: "ssh://first, admin@[2001:db8::1]:22",
+			want:  []SSHConfig{{Host: "first"}, {Host: "2001:db8::1", User: "admin", Port: 22}},
+		},
+		{name: "invalid port", value: "bastion:0", wantErr: true},
+		{name: "empty host", value: "jump@", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProxyJump(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseProxyJump(%q) = %+v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProxyJump(%q) error = %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProxyJump(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
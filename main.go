@@ -33,6 +33,21 @@ func basicAuth(username, password string, handler http.Handler) http.Handler {
 	})
 }
 
+// metricsHandler 处理 /metrics 请求，SSH指标在请求的context下采集，客户端断开或超时后终止正在执行的命令
+// 默认注册表中的Go运行时和进程指标照常输出
+func metricsHandler(sshCollector *collector.SSHCollector) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(sshCollector.WithContext(r.Context())); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, registry}
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handler)
+}
+
 func main() {
 	flag.Parse()
 
@@ -45,12 +60,22 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
-	logger.Printf("Loaded configuration for %d hosts", len(cfg.Hosts))
+	logger.Printf("Loaded configuration for %d hosts and %d probe modules", len(cfg.Hosts), len(cfg.Modules))
 
-	// 创建并注册collector
-	sshCollector := collector.NewSSHCollector(cfg)
-	prometheus.MustRegister(sshCollector)
-	logger.Println("SSH Collector registered")
+	// 创建collector，每次 /metrics 请求时在请求的context下采集
+	sshCollector, err := collector.NewSSHCollector(cfg)
+	if err != nil {
+		logger.Fatalf("Failed to create collector: %v", err)
+	}
+	// 启动时注册一次，检查指标描述（例如自定义监控器的指标与内置指标重名），
+	// 每次请求只传入请求的context，不再重复检查
+	if err := prometheus.NewRegistry().Register(sshCollector); err != nil {
+		logger.Fatalf("Failed to register collector: %v", err)
+	}
+	logger.Println("SSH Collector created")
+	if cfg.Background.Enabled {
+		sshCollector.StartBackground()
+	}
 
 	// 确定监听地址 - 命令行参数优先于配置文件
 	listen := *listenAddr
@@ -63,7 +88,8 @@ func main() {
 	}
 
 	// 设置HTTP处理器
-	metricsHandler := promhttp.Handler()
+	metricsHTTPHandler := metricsHandler(sshCollector)
+	var probeHTTPHandler http.Handler = probeHandler(cfg, sshCollector)
 	indexHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`<html>
@@ -71,6 +97,7 @@ func main() {
 <body>
 <h1>SSH Exporter</h1>
 <p><a href="/metrics">Metrics</a></p>
+<p><a href="/probe?target=localhost:22&amp;module=default">Probe</a> (requires <code>modules</code> in configuration)</p>
 <h2>Configuration</h2>
 <p>Monitoring ` + string(rune(len(cfg.Hosts))) + ` hosts</p>
 </body>
@@ -78,15 +105,17 @@ func main() {
 	})
 
 	// 应用HTTP基本认证（如果配置了的话）
-	var finalMetricsHandler http.Handler = metricsHandler
+	finalMetricsHandler := metricsHTTPHandler
 	var finalIndexHandler http.Handler = indexHandler
 	if cfg.HTTPAuth != nil && cfg.HTTPAuth.Username != "" && cfg.HTTPAuth.Password != "" {
 		logger.Println("HTTP basic authentication enabled")
-		finalMetricsHandler = basicAuth(cfg.HTTPAuth.Username, cfg.HTTPAuth.Password, metricsHandler)
+		finalMetricsHandler = basicAuth(cfg.HTTPAuth.Username, cfg.HTTPAuth.Password, metricsHTTPHandler)
 		finalIndexHandler = basicAuth(cfg.HTTPAuth.Username, cfg.HTTPAuth.Password, indexHandler)
+		probeHTTPHandler = basicAuth(cfg.HTTPAuth.Username, cfg.HTTPAuth.Password, probeHTTPHandler)
 	}
 
 	http.Handle("/metrics", finalMetricsHandler)
+	http.Handle("/probe", probeHTTPHandler)
 	http.Handle("/", finalIndexHandler)
 
 	// 启动HTTP服务器
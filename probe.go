@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ssh_exporter/collector"
+	"ssh_exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	defaultProbeModule  = "default"
+	defaultProbeTimeout = 10 * time.Second
+	probeTimeoutOffset  = 500 * time.Millisecond // 为HTTP响应预留的时间
+)
+
+// probeHandler 处理 /probe?target=host:port&module=name 请求
+func probeHandler(cfg *config.Config, sshCollector *collector.SSHCollector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		target := params.Get("target")
+		if target == "" {
+			http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+			return
+		}
+
+		moduleName := params.Get("module")
+		if moduleName == "" {
+			moduleName = defaultProbeModule
+		}
+		module, ok := cfg.Modules[moduleName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Unknown module %q", moduleName), http.StatusBadRequest)
+			return
+		}
+
+		hostConfig, err := probeHostConfig(module, target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		probeCollector, err := sshCollector.NewProbeCollector(r.Context(), moduleName, hostConfig, probeTimeout(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(probeCollector); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// probeHostConfig 使用模块配置和target生成主机配置，target中未指定端口时使用模块的端口
+// target整体作为host标签和按主机保存状态的键，同一主机的不同端口互不影响
+func probeHostConfig(module config.HostConfig, target string) (config.HostConfig, error) {
+	hostConfig := module
+	hostConfig.Host = target
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		// 没有端口部分
+		hostConfig.HostName = target
+		return hostConfig, nil
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return hostConfig, fmt.Errorf("invalid port in target %q", target)
+	}
+	hostConfig.HostName = host
+	hostConfig.Port = port
+	return hostConfig, nil
+}
+
+// probeTimeout 根据Prometheus的抓取超时时间确定探测超时
+func probeTimeout(r *http.Request) time.Duration {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return defaultProbeTimeout
+	}
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		return defaultProbeTimeout
+	}
+	timeout := time.Duration(seconds*float64(time.Second)) - probeTimeoutOffset
+	if timeout <= 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	return timeout
+}
@@ -0,0 +1,247 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// 认证方式名称
+const (
+	AuthPublicKey           = "publickey"
+	AuthPassword            = "password"
+	AuthKeyboardInteractive = "keyboard-interactive"
+)
+
+// KeyboardInteractiveAnswer keyboard-interactive认证中对某类提示的预设回答
+type KeyboardInteractiveAnswer struct {
+	Prompt string // 匹配提示内容的正则表达式
+	Answer string // 回答
+}
+
+// keyboardAnswer 已编译的预设回答
+type keyboardAnswer struct {
+	prompt *regexp.Regexp
+	answer string
+}
+
+// authenticator 按顺序尝试的认证方式
+type authenticator struct {
+	methods     []string     // 认证方式顺序
+	signers     []ssh.Signer // 私钥（publickey）
+	cert        *certificate // 用户证书（publickey，可选）
+	agentSocket string       // ssh-agent套接字（publickey）
+	password    string
+	answers     []keyboardAnswer
+}
+
+// newAuthenticator 根据客户端参数创建认证器
+// 未配置认证方式顺序时：有私钥或ssh-agent则使用publickey，否则使用password
+func newAuthenticator(opts Options) (*authenticator, error) {
+	a := &authenticator{password: opts.Password}
+
+	if opts.PrivateKeyPath != "" {
+		passphrase, err := readPassphrase(opts.Passphrase, opts.PassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := loadPrivateKey(opts.PrivateKeyPath, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key: %w", err)
+		}
+		a.signers = append(a.signers, key)
+
+		cert, err := newCertificate(opts.Certificate, opts.PrivateKeyPath, key)
+		if err != nil {
+			return nil, err
+		}
+		a.cert = cert
+	} else if opts.Certificate != "" {
+		return nil, fmt.Errorf("certificate requires private_key")
+	}
+
+	if opts.UseAgent {
+		a.agentSocket = opts.AgentSocket
+		if a.agentSocket == "" {
+			a.agentSocket = os.Getenv("SSH_AUTH_SOCK")
+		}
+		if a.agentSocket == "" {
+			return nil, fmt.Errorf("ssh-agent requested but SSH_AUTH_SOCK is not set")
+		}
+	}
+
+	for _, ka := range opts.KeyboardInteractive {
+		re, err := regexp.Compile(ka.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keyboard-interactive prompt %q: %w", ka.Prompt, err)
+		}
+		a.answers = append(a.answers, keyboardAnswer{prompt: re, answer: ka.Answer})
+	}
+
+	a.methods = opts.AuthMethods
+	if len(a.methods) == 0 {
+		if len(a.signers) > 0 || a.agentSocket != "" {
+			a.methods = []string{AuthPublicKey}
+		} else if a.password != "" {
+			a.methods = []string{AuthPassword}
+		} else {
+			return nil, fmt.Errorf("neither password, private key nor ssh-agent provided")
+		}
+	}
+
+	for _, method := range a.methods {
+		switch method {
+		case AuthPublicKey:
+			if len(a.signers) == 0 && a.agentSocket == "" {
+				return nil, fmt.Errorf("publickey authentication requires private_key or ssh-agent")
+			}
+		case AuthPassword:
+			if a.password == "" {
+				return nil, fmt.Errorf("password authentication requires password")
+			}
+		case AuthKeyboardInteractive:
+			if len(a.answers) == 0 && a.password == "" {
+				return nil, fmt.Errorf("keyboard-interactive authentication requires answers or password")
+			}
+		default:
+			return nil, fmt.Errorf("unknown auth method %q", method)
+		}
+	}
+
+	return a, nil
+}
+
+// authMethods 为一次连接创建认证方式，used记录最近一次被尝试的方式，认证成功后即为成功的方式
+// 返回的cleanup需要在认证完成后调用
+func (a *authenticator) authMethods(used *string) ([]ssh.AuthMethod, func(), error) {
+	var methods []ssh.AuthMethod
+	var agentConn net.Conn
+	cleanup := func() {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+	}
+
+	for _, method := range a.methods {
+		switch method {
+		case AuthPublicKey:
+			signers := a.signers
+			if a.cert != nil {
+				// 每次连接时检查证书文件是否被轮换，证书优先于私钥
+				certSigner, err := a.cert.load()
+				if err != nil {
+					cleanup()
+					return nil, nil, err
+				}
+				if certSigner != nil {
+					signers = append([]ssh.Signer{certSigner}, signers...)
+				}
+			}
+			var agentClient agent.ExtendedAgent
+			if a.agentSocket != "" {
+				// agent连接只在认证期间使用
+				conn, err := net.Dial("unix", a.agentSocket)
+				if err != nil {
+					cleanup()
+					return nil, nil, fmt.Errorf("failed to connect to ssh-agent at %s: %w", a.agentSocket, err)
+				}
+				agentConn = conn
+				agentClient = agent.NewClient(conn)
+			}
+			methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				*used = AuthPublicKey
+				if agentClient == nil {
+					return signers, nil
+				}
+				agentSigners, err := agentClient.Signers()
+				if err != nil {
+					return nil, err
+				}
+				return append(append([]ssh.Signer{}, signers...), agentSigners...), nil
+			}))
+
+		case AuthPassword:
+			methods = append(methods, ssh.PasswordCallback(func() (string, error) {
+				*used = AuthPassword
+				return a.password, nil
+			}))
+
+		case AuthKeyboardInteractive:
+			methods = append(methods, ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				*used = AuthKeyboardInteractive
+				return a.answer(questions)
+			}))
+		}
+	}
+
+	return methods, cleanup, nil
+}
+
+// answer 使用预设回答响应keyboard-interactive提示，未匹配的密码提示使用password
+func (a *authenticator) answer(questions []string) ([]string, error) {
+	answers := make([]string, len(questions))
+	for i, question := range questions {
+		matched := false
+		for _, ka := range a.answers {
+			if ka.prompt.MatchString(question) {
+				answers[i] = ka.answer
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if a.password != "" && strings.Contains(strings.ToLower(question), "password") {
+			answers[i] = a.password
+			continue
+		}
+		return nil, fmt.Errorf("no answer configured for keyboard-interactive prompt %q", question)
+	}
+	return answers, nil
+}
+
+// loadPrivateKey 从文件加载SSH私钥，passphrase不为空时解密受密码保护的私钥
+func loadPrivateKey(path, passphrase string) (ssh.Signer, error) {
+	keyData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	if passphrase != "" {
+		key, err := ssh.ParsePrivateKeyWithPassphrase(keyData, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("private key is encrypted, set private_key_passphrase or private_key_passphrase_file")
+		}
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	return key, nil
+}
+
+// readPassphrase 返回私钥密码，配置了密码文件时从文件读取（去掉末尾换行）
+func readPassphrase(passphrase, passphraseFile string) (string, error) {
+	if passphraseFile == "" {
+		return passphrase, nil
+	}
+	data, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,261 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newTestKey 生成测试用的ed25519私钥
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+// writeEncryptedKey 将私钥以passphrase加密后写入临时文件，返回文件路径
+func writeEncryptedKey(t *testing.T, key ed25519.PrivateKey, passphrase string) string {
+	t.Helper()
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrivateKeyEncrypted(t *testing.T) {
+	key := newTestKey(t)
+	path := writeEncryptedKey(t, key, "secret")
+	want, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		wantErr    string
+	}{
+		{name: "correct passphrase", passphrase: "secret"},
+		{name: "wrong passphrase", passphrase: "wrong", wantErr: "failed to decrypt private key"},
+		{name: "missing passphrase", passphrase: "", wantErr: "private key is encrypted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := loadPrivateKey(path, tt.passphrase)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadPrivateKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadPrivateKey() error = %v", err)
+			}
+			if ssh.FingerprintSHA256(signer.PublicKey()) != ssh.FingerprintSHA256(want) {
+				t.Errorf("loadPrivateKey() loaded %s, want %s", ssh.FingerprintSHA256(signer.PublicKey()), ssh.FingerprintSHA256(want))
+			}
+		})
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "newline", content: "secret\n", want: "secret"},
+		{name: "crlf", content: "secret\r\n", want: "secret"},
+		{name: "several newlines", content: "secret\n\n", want: "secret"},
+		{name: "no newline", content: "secret", want: "secret"},
+		{name: "spaces kept", content: " secret \n", want: " secret "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "passphrase")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readPassphrase("ignored", path)
+			if err != nil {
+				t.Fatalf("readPassphrase() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readPassphrase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no file", func(t *testing.T) {
+		got, err := readPassphrase("secret", "")
+		if err != nil || got != "secret" {
+			t.Errorf("readPassphrase() = %q, %v, want %q", got, err, "secret")
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := readPassphrase("", filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("readPassphrase() succeeded for a missing file")
+		}
+	})
+}
+
+// serveAgent 在临时unix套接字上提供keyring，返回套接字路径
+func serveAgent(t *testing.T, keyring agent.Agent) string {
+	t.Helper()
+	// unix套接字路径长度有限，不使用较长的t.TempDir()
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	return socket
+}
+
+// serveSSH 启动只接受accepted公钥的SSH服务器，返回监听地址
+func serveSSH(t *testing.T, accepted ssh.PublicKey) string {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if ssh.FingerprintSHA256(key) == ssh.FingerprintSHA256(accepted) {
+				return nil, nil
+			}
+			return nil, errors.New("public key not accepted")
+		},
+	}
+	hostKey, err := ssh.NewSignerFromKey(newTestKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sshConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				defer sshConn.Close()
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					ch.Reject(ssh.Prohibited, "no channels")
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestAgentSigners(t *testing.T) {
+	agentKey := newTestKey(t)
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: agentKey}); err != nil {
+		t.Fatal(err)
+	}
+	socket := serveAgent(t, keyring)
+
+	agentPublicKey, err := ssh.NewPublicKey(agentKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveSSH(t, agentPublicKey)
+
+	// 同时配置了不被服务器接受的私钥文件，agent中的密钥在其之后尝试
+	fileKey := writeEncryptedKey(t, newTestKey(t), "secret")
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "agent only", opts: Options{UseAgent: true, AgentSocket: socket}},
+		{name: "agent after private key", opts: Options{UseAgent: true, AgentSocket: socket, PrivateKeyPath: fileKey, Passphrase: "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := newAuthenticator(tt.opts)
+			if err != nil {
+				t.Fatalf("newAuthenticator() error = %v", err)
+			}
+
+			var used string
+			methods, cleanup, err := auth.authMethods(&used)
+			if err != nil {
+				t.Fatalf("authMethods() error = %v", err)
+			}
+			defer cleanup()
+
+			client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+				User:            "test",
+				Auth:            methods,
+				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			})
+			if err != nil {
+				t.Fatalf("authentication with agent key failed: %v", err)
+			}
+			client.Close()
+
+			if used != AuthPublicKey {
+				t.Errorf("used auth method = %q, want %q", used, AuthPublicKey)
+			}
+		})
+	}
+}
+
+func TestAgentSocketMissing(t *testing.T) {
+	auth, err := newAuthenticator(Options{UseAgent: true, AgentSocket: filepath.Join(t.TempDir(), "missing.sock")})
+	if err != nil {
+		t.Fatalf("newAuthenticator() error = %v", err)
+	}
+	var used string
+	if _, _, err := auth.authMethods(&used); err == nil || !strings.Contains(err.Error(), "failed to connect to ssh-agent") {
+		t.Errorf("authMethods() error = %v, want ssh-agent connection error", err)
+	}
+}
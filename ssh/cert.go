@@ -0,0 +1,135 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// certificateSuffix OpenSSH约定的证书文件后缀，证书默认放在私钥旁边
+const certificateSuffix = "-cert.pub"
+
+// certificate 用户证书文件，文件在磁盘上被替换（轮换）后自动重新加载
+type certificate struct {
+	path     string
+	optional bool // 未显式配置的默认证书文件，不存在时忽略
+	key      ssh.Signer
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	signer  ssh.Signer
+}
+
+// newCertificate 创建证书，path为空时使用私钥旁边的默认证书文件（不存在则忽略）
+func newCertificate(path, keyPath string, key ssh.Signer) (*certificate, error) {
+	c := &certificate{path: path, key: key}
+	if path == "" {
+		c.path = keyPath + certificateSuffix
+		c.optional = true
+	}
+	if _, err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load 返回证书签名器，文件修改时间或大小变化时重新读取
+// 默认证书文件不存在时返回nil
+func (c *certificate) load() (ssh.Signer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		if c.optional && errors.Is(err, os.ErrNotExist) {
+			c.signer = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	if c.signer != nil && info.ModTime().Equal(c.modTime) && info.Size() == c.size {
+		return c.signer, nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %w", c.path, err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an SSH certificate", c.path)
+	}
+	signer, err := ssh.NewCertSigner(cert, c.key)
+	if err != nil {
+		return nil, fmt.Errorf("certificate %s does not match private key: %w", c.path, err)
+	}
+
+	if c.signer != nil {
+		logger.Printf("Reloaded certificate %s (serial %d)", c.path, cert.Serial)
+	}
+	if before := time.Unix(int64(cert.ValidBefore), 0); cert.ValidBefore != ssh.CertTimeInfinity && time.Now().After(before) {
+		logger.Printf("Certificate %s expired at %s", c.path, before.Format(time.RFC3339))
+	}
+	c.signer = signer
+	c.modTime = info.ModTime()
+	c.size = info.Size()
+	return signer, nil
+}
+
+// newHostCACallback 创建按主机CA校验主机证书的回调，caFile为authorized_keys格式的CA公钥文件
+// 主机必须出示由CA签发、在有效期内且principal包含主机名的证书
+func newHostCACallback(caFile string) (ssh.HostKeyCallback, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host CA file: %w", err)
+	}
+
+	var authorities []ssh.PublicKey
+	for len(data) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			if len(authorities) > 0 {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse host CA file %s: %w", caFile, err)
+		}
+		authorities = append(authorities, key)
+		data = rest
+	}
+	if len(authorities) == 0 {
+		return nil, fmt.Errorf("no CA keys found in %s", caFile)
+	}
+
+	checker := &ssh.CertChecker{
+		IsHostAuthority: func(auth ssh.PublicKey, address string) bool {
+			for _, ca := range authorities {
+				if ssh.FingerprintSHA256(ca) == ssh.FingerprintSHA256(auth) {
+					return true
+				}
+			}
+			return false
+		},
+		HostKeyFallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return fmt.Errorf("%w: %s presented a plain host key %s instead of a certificate", ErrHostKeyUnknown, hostname, ssh.FingerprintSHA256(key))
+		},
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := checker.CheckHostKey(hostname, remote, key); err != nil {
+			if errors.Is(err, ErrHostKeyUnknown) {
+				return err
+			}
+			return fmt.Errorf("%w: %s: %w", ErrHostKeyMismatch, hostname, err)
+		}
+		return nil
+	}, nil
+}
@@ -1,106 +1,511 @@
 package ssh
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"ssh_exporter/transport"
+
 	"golang.org/x/crypto/ssh"
 )
 
+// Client 实现 transport.Executor
+var _ transport.Executor = (*Client)(nil)
+
+var logger = log.New(os.Stdout, "[SSH] ", log.LstdFlags)
+
+// ErrInvalidConfig 客户端配置错误（例如私钥无法加载）
+var ErrInvalidConfig = errors.New("invalid ssh client config")
+
+// connectionSeq 连接序号，所有客户端共用，保证每个连接的序号唯一
+var connectionSeq atomic.Uint64
+
+// Options SSH客户端参数
+type Options struct {
+	Host           string // 主机名称，用于日志和指标标签
+	HostName       string // 实际连接的地址（可选，默认与Host相同）
+	Port           int
+	User           string
+	Password       string // SSH密码（可选）
+	PrivateKeyPath string // SSH私钥路径（可选）
+	Certificate    string // 用户证书路径（可选，默认使用私钥旁边的 <私钥>-cert.pub）
+	Passphrase     string // 私钥密码（可选）
+	PassphraseFile string // 私钥密码文件（可选，优先于Passphrase）
+	UseAgent       bool   // 是否通过ssh-agent认证
+	AgentSocket    string // ssh-agent套接字路径，为空时使用SSH_AUTH_SOCK
+
+	AuthMethods         []string                    // 按顺序尝试的认证方式（可选）
+	KeyboardInteractive []KeyboardInteractiveAnswer // keyboard-interactive认证的预设回答
+
+	HostKey HostKeyOptions // 主机密钥校验配置
+
+	JumpHosts []Options // 依次经过的跳板机（可选），跳板机自身的JumpHosts被忽略
+	Proxy     string    // SOCKS5或HTTP CONNECT代理地址（可选），配置了跳板机时只用于第一个跳板机
+}
+
+// jumpOptions 返回连接到最后一个跳板机所需的参数（经过其之前的跳板机）
+func jumpOptions(hops []Options) Options {
+	last := hops[len(hops)-1]
+	last.JumpHosts = hops[:len(hops)-1]
+	return last
+}
+
 // Client SSH客户端
 type Client struct {
-	config *ssh.ClientConfig
-	host   string
-	port   int
-	conn   *ssh.Client
+	config   *ssh.ClientConfig
+	host     string
+	hostname string // 实际连接的地址
+	port     int
+
+	metrics *Metrics       // 连接指标（可选）
+	auth    *authenticator // 认证方式，每次建立连接时创建
+	hostKey *hostKeyChecker
+
+	jump     *Client      // 跳板机客户端（可选），通过其连接转发到目标主机
+	ownsJump bool         // 跳板机客户端由该客户端创建，关闭时一并关闭
+	proxy    *proxyDialer // 代理（可选），未使用跳板机时通过代理连接
+
+	mu         sync.Mutex
+	conn       *ssh.Client
+	dialing    *dialCall // 正在建立的连接（可选）
+	authMethod string    // 当前连接认证成功的方式
+	connID     uint64    // 当前连接的序号
+}
+
+// dialCall 正在建立的连接，同一客户端同时只建立一个连接，其他调用方等待其结果
+type dialCall struct {
+	done chan struct{}
+	conn *ssh.Client
+	err  error
 }
 
 // NewClient 创建新的SSH客户端
-// 如果提供了privateKeyPath，将使用私钥认证；否则使用密码认证
-func NewClient(host, user, password, privateKeyPath string, port int) (*Client, error) {
-	var authMethods []ssh.AuthMethod
+// 认证方式按opts.AuthMethods的顺序尝试；未配置时有私钥或ssh-agent则使用私钥认证，否则使用密码认证
+// 配置了跳板机时同时创建跳板机客户端（连接池中的客户端则共享跳板机连接）
+func NewClient(opts Options) (*Client, error) {
+	c, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.JumpHosts) > 0 {
+		jumpOpts := jumpOptions(opts.JumpHosts)
+		jump, err := NewClient(jumpOpts)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", jumpOpts.Host, err)
+		}
+		c.jump = jump
+		c.ownsJump = true
+	}
+	return c, nil
+}
+
+// newClient 创建不含跳板机的SSH客户端
+func newClient(opts Options) (*Client, error) {
+	auth, err := newAuthenticator(opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	hostKey, err := newHostKeyChecker(opts.HostKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to set up host key checking: %w", ErrInvalidConfig, err)
+	}
 
-	// 优先使用私钥认证
-	if privateKeyPath != "" {
-		key, err := loadPrivateKey(privateKeyPath)
+	var proxy *proxyDialer
+	if opts.Proxy != "" && len(opts.JumpHosts) == 0 {
+		proxy, err = newProxyDialer(opts.Proxy)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load private key: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
 		}
-		authMethods = append(authMethods, ssh.PublicKeys(key))
-	} else if password != "" {
-		// 使用密码认证
-		authMethods = append(authMethods, ssh.Password(password))
-	} else {
-		return nil, fmt.Errorf("neither password nor private key provided")
 	}
 
 	config := &ssh.ClientConfig{
-		User:            user,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 信任所有主机密钥
+		User:            opts.User,
+		HostKeyCallback: hostKey.callback,
 		Timeout:         10 * time.Second,
 	}
 
+	hostname := opts.HostName
+	if hostname == "" {
+		hostname = opts.Host
+	}
+
 	return &Client{
-		config: config,
-		host:   host,
-		port:   port,
+		config:   config,
+		host:     opts.Host,
+		hostname: hostname,
+		port:     opts.Port,
+		auth:     auth,
+		hostKey:  hostKey,
+		proxy:    proxy,
 	}, nil
 }
 
-// loadPrivateKey 从文件加载SSH私钥
-func loadPrivateKey(path string) (ssh.Signer, error) {
-	keyData, err := os.ReadFile(path)
+// Connect 连接到SSH服务器，已有连接时关闭后重新连接
+func (c *Client) Connect() error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	_, err := c.connect(context.Background(), conn)
+	return err
+}
+
+// connect 在当前连接为old（old为nil表示尚未连接）时建立新连接，其他goroutine已经建立新连接时直接使用
+// 建立连接期间不持有c.mu，已有goroutine在建立连接时等待其结果，直到ctx结束
+func (c *Client) connect(ctx context.Context, old *ssh.Client) (*ssh.Client, error) {
+	c.mu.Lock()
+	if c.conn != nil && c.conn != old {
+		conn := c.conn
+		c.mu.Unlock()
+		return conn, nil
+	}
+	if call := c.dialing; call != nil {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.conn, call.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to connect to %s: %w", c.host, ctx.Err())
+		}
+	}
+	if old != nil {
+		logger.Printf("Reconnecting to %s:%d", c.host, c.port)
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	call := &dialCall{done: make(chan struct{})}
+	c.dialing = call
+	c.mu.Unlock()
+
+	conn, authMethod, err := c.establish(ctx)
+
+	c.mu.Lock()
+	c.dialing = nil
+	if err == nil {
+		c.conn = conn
+		c.authMethod = authMethod
+		c.connID = connectionSeq.Add(1)
+	}
+	c.mu.Unlock()
+	call.conn, call.err = conn, err
+	close(call.done)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read private key file: %w", err)
+		return nil, err
 	}
 
-	// 尝试解析私钥（支持无密码保护的私钥）
-	key, err := ssh.ParsePrivateKey(keyData)
+	// 连接断开后清除，下次使用时自动重连
+	go func() {
+		conn.Wait()
+		c.mu.Lock()
+		if c.conn == conn {
+			c.conn = nil
+		}
+		c.mu.Unlock()
+	}()
+
+	return conn, nil
+}
+
+// establish 建立新连接，返回连接和认证成功的方式
+func (c *Client) establish(ctx context.Context) (*ssh.Client, string, error) {
+	var authMethod string
+	authMethods, cleanup, err := c.auth.authMethods(&authMethod)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %w", err)
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidConfig, err)
 	}
+	defer cleanup()
+	config := *c.config
+	config.Auth = authMethods
 
-	return key, nil
+	addr := fmt.Sprintf("%s:%d", c.hostname, c.port)
+	start := time.Now()
+	conn, err := c.dialHostKey(ctx, addr, &config)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	c.metrics.observeHandshake(c.host, time.Since(start))
+	return conn, authMethod, nil
 }
 
-// Connect 连接到SSH服务器
-func (c *Client) Connect() error {
-	addr := fmt.Sprintf("%s:%d", c.host, c.port)
-	conn, err := ssh.Dial("tcp", addr, c.config)
+// dialHostKey 建立SSH连接，只协商主机已知密钥类型的算法
+// 固定的指纹不带密钥类型，服务器出示的密钥与指纹不一致时排除该类型后重试，
+// 直到服务器没有其他类型的密钥，此时返回密钥不一致的错误
+func (c *Client) dialHostKey(ctx context.Context, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	config.HostKeyAlgorithms = c.hostKey.hostKeyAlgorithms(addr)
+	var mismatch error
+	for {
+		conn, err := c.dial(ctx, addr, config)
+		if err == nil {
+			return conn, nil
+		}
+		var negotiation *ssh.AlgorithmNegotiationError
+		if mismatch != nil && errors.As(err, &negotiation) {
+			return nil, mismatch
+		}
+		algorithms, retry := c.hostKey.excludeMismatch(config.HostKeyAlgorithms, err)
+		if !retry {
+			return nil, err
+		}
+		mismatch = err
+		config.HostKeyAlgorithms = algorithms
+	}
+}
+
+// dial 建立SSH连接，配置了跳板机时通过跳板机的连接转发，配置了代理时通过代理连接
+// config.Timeout限制TCP连接、代理握手和SSH握手的总时间（不包括连接跳板机的时间），ctx结束时立即中断
+func (c *Client) dial(ctx context.Context, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var jumpConn *ssh.Client
+	if c.jump != nil {
+		var err error
+		jumpConn, err = c.jump.connection(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s:%d: %w", c.jump.host, c.jump.port, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var netConn net.Conn
+	var err error
+	switch {
+	case jumpConn != nil:
+		netConn, err = jumpConn.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("dial via jump host %s:%d: %w", c.jump.host, c.jump.port, err)
+		}
+	case c.proxy != nil:
+		netConn, err = c.proxy.dial(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		var dialer net.Dialer
+		netConn, err = dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 握手没有超时，ctx结束时关闭连接使握手返回；经过跳板机的连接不支持SetDeadline，因此直接关闭
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if !stop() {
+		if err == nil {
+			sshConn.Close()
+		}
+		return nil, fmt.Errorf("ssh handshake with %s: %w", addr, ctx.Err())
+	}
 	if err != nil {
-		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+		netConn.Close()
+		return nil, err
 	}
-	c.conn = conn
-	return nil
+	return ssh.NewClient(sshConn, chans, reqs), nil
 }
 
-// ExecuteCommand 执行命令
-func (c *Client) ExecuteCommand(command string) (string, error) {
-	if c.conn == nil {
-		return "", fmt.Errorf("not connected")
+// connection 返回当前连接，未连接时自动建立连接
+func (c *Client) connection(ctx context.Context) (*ssh.Client, error) {
+	return c.connect(ctx, nil)
+}
+
+// reconnect 在连接conn失效时重新连接；如果其他goroutine已经重连则直接使用新连接
+func (c *Client) reconnect(ctx context.Context, conn *ssh.Client) (*ssh.Client, error) {
+	return c.connect(ctx, conn)
+}
+
+// connectionLost 判断创建会话失败是否因为连接conn已经断开
+func (c *Client) connectionLost(conn *ssh.Client, err error) bool {
+	var openErr *ssh.OpenChannelError
+	if errors.As(err, &openErr) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	// 连接断开后Wait返回，c.conn会被清除
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != conn
+}
+
+// AuthMethod 返回当前连接认证成功的方式
+func (c *Client) AuthMethod() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authMethod
+}
+
+// ConnectionID 返回最近一次建立的连接的序号（尚未连接时为0），每次重新连接都会变化
+// 可以用来缓存在一次连接期间不变的主机信息
+func (c *Client) ConnectionID() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connID
+}
+
+// Connected 返回当前是否持有连接
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// ExecuteCommand 执行命令，ctx结束时向远程进程发送SIGKILL并关闭会话
+// 远程命令的退出码、信号和输出通过Result返回，error只表示命令无法执行
+func (c *Client) ExecuteCommand(ctx context.Context, command string) (transport.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return transport.Result{}, err
 	}
 
-	session, err := c.conn.NewSession()
+	conn, err := c.connection(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to create session: %w", err)
+		return transport.Result{}, err
 	}
-	defer session.Close()
 
-	output, err := session.CombinedOutput(command)
+	session, err := conn.NewSession()
 	if err != nil {
-		// 即使有错误，也返回输出（某些命令可能会有部分输出）
-		return string(output), err
+		// 服务器拒绝打开会话（如超过MaxSessions）时连接仍然可用，直接返回错误；
+		// 只有连接已经断开时才重连后重试一次
+		if !c.connectionLost(conn, err) {
+			return transport.Result{}, fmt.Errorf("failed to create session: %w", err)
+		}
+		conn, err = c.reconnect(ctx, conn)
+		if err != nil {
+			return transport.Result{}, err
+		}
+		session, err = conn.NewSession()
+		if err != nil {
+			return transport.Result{}, fmt.Errorf("failed to create session: %w", err)
+		}
 	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
 
-	return string(output), nil
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// 服务器不支持signal请求时，关闭会话也会让命令的输出管道断开
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		<-done
+		return transport.Result{
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			ExitCode: -1,
+			Duration: time.Since(start),
+		}, fmt.Errorf("command on %s: %w", c.host, ctx.Err())
+	}
+
+	result := transport.Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitStatus()
+		if exitErr.Signal() != "" {
+			result.ExitCode = -1
+			result.Signal = exitErr.Signal()
+		}
+		return result, nil
+	}
+	return result, err
 }
 
-// Close 关闭连接
+// Keepalive 发送keepalive请求检查连接，失败时关闭连接
+func (c *Client) Keepalive(timeout time.Duration) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+		errCh <- err
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-time.After(timeout):
+		err = fmt.Errorf("keepalive timed out after %s", timeout)
+	}
+	if err != nil {
+		// 关闭连接，Wait返回后连接会被清除
+		conn.Close()
+		return err
+	}
+	return nil
+}
+
+// Close 关闭连接，跳板机客户端由该客户端创建时一并关闭
 func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
 	if c.conn != nil {
-		return c.conn.Close()
+		err = c.conn.Close()
+		c.conn = nil
+	}
+	if c.ownsJump {
+		c.jump.Close()
+	}
+	return err
+}
+
+// 连接失败原因，用于指标标签
+const (
+	ReasonConfig          = "config"
+	ReasonDial            = "dial"
+	ReasonAuth            = "auth"
+	ReasonHostKeyMismatch = "host_key_mismatch"
+	ReasonHostKeyUnknown  = "host_key_unknown"
+	ReasonProxy           = "proxy"
+	ReasonHandshake       = "handshake"
+)
+
+// FailureReason 将连接错误归类为指标中使用的原因
+func FailureReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrInvalidConfig):
+		return ReasonConfig
+	case errors.Is(err, ErrHostKeyMismatch):
+		return ReasonHostKeyMismatch
+	case errors.Is(err, ErrHostKeyUnknown):
+		return ReasonHostKeyUnknown
+	case errors.Is(err, ErrProxy):
+		return ReasonProxy
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return ReasonAuth
+	case errors.As(err, &netErr):
+		return ReasonDial
+	default:
+		return ReasonHandshake
 	}
-	return nil
 }
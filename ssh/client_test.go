@@ -0,0 +1,152 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testClientOptions 返回用key认证连接到addr的客户端参数
+func testClientOptions(t *testing.T, addr string, key string) Options {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Options{Host: host, Port: p, User: "test", PrivateKeyPath: key, Passphrase: "secret"}
+}
+
+func TestExecuteCommandSessionRejected(t *testing.T) {
+	key := newTestKey(t)
+	publicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 测试服务器拒绝所有会话，连接本身保持可用
+	addr := serveSSH(t, publicKey)
+
+	client, err := newClient(testClientOptions(t, addr, writeEncryptedKey(t, key, "secret")))
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	connID := client.ConnectionID()
+
+	_, err = client.ExecuteCommand(context.Background(), "true")
+	var openErr *ssh.OpenChannelError
+	if !errors.As(err, &openErr) || openErr.Reason != ssh.Prohibited {
+		t.Fatalf("ExecuteCommand() error = %v, want administratively prohibited", err)
+	}
+	if got := client.ConnectionID(); got != connID {
+		t.Errorf("ConnectionID() = %d after rejected session, want %d (no reconnect)", got, connID)
+	}
+}
+
+func TestExecuteCommandReconnect(t *testing.T) {
+	key := newTestKey(t)
+	publicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveSSH(t, publicKey)
+
+	client, err := newClient(testClientOptions(t, addr, writeEncryptedKey(t, key, "secret")))
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer client.Close()
+	conn, err := client.connection(context.Background())
+	if err != nil {
+		t.Fatalf("connection() error = %v", err)
+	}
+	connID := client.ConnectionID()
+
+	// 连接断开后重连，重连后的会话仍被服务器拒绝
+	conn.Close()
+	_, err = client.ExecuteCommand(context.Background(), "true")
+	var openErr *ssh.OpenChannelError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("ExecuteCommand() error = %v, want channel open error after reconnect", err)
+	}
+	if got := client.ConnectionID(); got == connID {
+		t.Errorf("ConnectionID() = %d, want a new connection after the old one was closed", got)
+	}
+}
+
+// serveSilent 启动接受TCP连接但不发送任何数据的服务器，返回监听地址
+func serveSilent(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conns []net.Conn
+	var mu sync.Mutex
+	t.Cleanup(func() {
+		listener.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestExecuteCommandHandshakeCanceled(t *testing.T) {
+	addr := serveSilent(t)
+	client, err := newClient(testClientOptions(t, addr, writeEncryptedKey(t, newTestKey(t), "secret")))
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// 两个调用方同时等待同一次握手，都应在各自的ctx结束后返回
+	timeouts := []time.Duration{200 * time.Millisecond, 400 * time.Millisecond}
+	errs := make(chan error, len(timeouts))
+	start := time.Now()
+	for _, timeout := range timeouts {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			_, err := client.ExecuteCommand(ctx, "true")
+			errs <- err
+		}()
+	}
+	for range timeouts {
+		if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ExecuteCommand() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ExecuteCommand() returned after %s, want shortly after the context deadline", elapsed)
+	}
+	if client.Connected() {
+		t.Error("Connected() = true after a canceled handshake")
+	}
+}
@@ -0,0 +1,309 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 主机密钥校验模式
+const (
+	HostKeyCheckingOff         = "off"         // 不校验主机密钥（InsecureIgnoreHostKey）
+	HostKeyCheckingStrict      = "strict"      // 严格按照known_hosts文件校验
+	HostKeyCheckingFingerprint = "fingerprint" // 按配置中固定的指纹校验
+	HostKeyCheckingTOFU        = "tofu"        // 首次信任，并将新密钥写入known_hosts文件
+	HostKeyCheckingCA          = "ca"          // 主机必须出示由配置的主机CA签发的证书
+)
+
+var (
+	// ErrHostKeyMismatch 主机密钥与已知密钥不一致
+	ErrHostKeyMismatch = errors.New("host key mismatch")
+	// ErrHostKeyUnknown 主机密钥未知（strict模式下不在known_hosts中）
+	ErrHostKeyUnknown = errors.New("host key unknown")
+)
+
+// hostKeyMismatchError 服务器出示的主机密钥与已知密钥不一致
+type hostKeyMismatchError struct {
+	hostname string
+	key      ssh.PublicKey // 服务器出示的密钥
+}
+
+func (e *hostKeyMismatchError) Error() string {
+	return fmt.Sprintf("%v: %s presented %s", ErrHostKeyMismatch, e.hostname, ssh.FingerprintSHA256(e.key))
+}
+
+func (e *hostKeyMismatchError) Unwrap() error {
+	return ErrHostKeyMismatch
+}
+
+// HostKeyOptions 主机密钥校验配置
+type HostKeyOptions struct {
+	Mode           string   // 校验模式，为空时等同于off
+	KnownHostsFile string   // known_hosts文件路径（strict和tofu模式使用）
+	Fingerprints   []string // 固定的主机密钥指纹（fingerprint模式使用）
+	CAFile         string   // 主机CA公钥文件（ca模式使用）
+	HostKeyAlias   string   // 校验时代替主机地址使用的名称（可选）
+}
+
+// knownHostsFile 共享的known_hosts文件，tofu模式下多个客户端会并发写入同一文件
+type knownHostsFile struct {
+	mu       sync.Mutex
+	path     string
+	callback ssh.HostKeyCallback
+}
+
+var (
+	knownHostsMu    sync.Mutex
+	knownHostsFiles = make(map[string]*knownHostsFile)
+)
+
+// openKnownHosts 获取known_hosts文件的共享实例，create为true时文件不存在会被创建
+func openKnownHosts(path string, create bool) (*knownHostsFile, error) {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if f, ok := knownHostsFiles[path]; ok {
+		return f, nil
+	}
+
+	if create {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create known_hosts directory: %w", err)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create known_hosts file: %w", err)
+		}
+		file.Close()
+	}
+
+	f := &knownHostsFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	knownHostsFiles[path] = f
+	return f, nil
+}
+
+// reload 重新读取known_hosts文件
+func (f *knownHostsFile) reload() error {
+	callback, err := knownhosts.New(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to load known_hosts %s: %w", f.path, err)
+	}
+	f.callback = callback
+	return nil
+}
+
+// check 校验主机密钥，trustNew为true时未知主机的密钥会被追加到文件中
+func (f *knownHostsFile) check(hostname string, remote net.Addr, key ssh.PublicKey, trustNew bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+	if len(keyErr.Want) > 0 {
+		return &hostKeyMismatchError{hostname: hostname, key: key}
+	}
+	if !trustNew {
+		return fmt.Errorf("%w: %s (%s) not found in %s", ErrHostKeyUnknown, hostname, ssh.FingerprintSHA256(key), f.path)
+	}
+
+	// 首次信任：写入新密钥后重新加载
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open known_hosts for writing: %w", err)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+	logger.Printf("Trusted new host key for %s: %s", hostname, ssh.FingerprintSHA256(key))
+
+	return f.reload()
+}
+
+// probeKey 查询known_hosts中已知密钥时使用的全零ed25519公钥，不会出现在文件中
+var probeKey, _ = ssh.NewPublicKey(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize)))
+
+// keyAlgorithms 返回known_hosts中hostname的已知密钥可以使用的算法，没有已知密钥时返回nil
+func (f *knownHostsFile) keyAlgorithms(hostname string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 查询失败时KeyError.Want中是该主机的全部已知密钥
+	var keyErr *knownhosts.KeyError
+	if !errors.As(f.callback(hostname, &net.TCPAddr{}, probeKey), &keyErr) {
+		return nil
+	}
+	var algorithms []string
+	for _, known := range keyErr.Want {
+		for _, algo := range keyTypeAlgorithms(known.Key.Type()) {
+			if !slices.Contains(algorithms, algo) {
+				algorithms = append(algorithms, algo)
+			}
+		}
+	}
+	return algorithms
+}
+
+// keyTypeAlgorithms 返回密钥类型可以使用的主机密钥算法
+func keyTypeAlgorithms(keyType string) []string {
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+// algorithmKeyType 返回主机密钥算法对应的密钥类型
+func algorithmKeyType(algo string) string {
+	switch algo {
+	case ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSASHA512:
+		return ssh.KeyAlgoRSA
+	case ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSASHA512v01:
+		return ssh.CertAlgoRSAv01
+	}
+	return algo
+}
+
+// hostKeyChecker 主机密钥校验回调以及连接时协商的主机密钥算法
+// x/crypto默认优先协商ECDSA，主机同时有多种密钥时只协商已知的类型，否则已知密钥只有ed25519的主机会被判为密钥不一致
+type hostKeyChecker struct {
+	callback ssh.HostKeyCallback
+	// algorithms 返回连接hostname时协商的算法，为nil或返回nil时使用默认顺序
+	algorithms func(hostname string) []string
+	// retryMismatch 固定的指纹不带密钥类型，密钥不一致时排除服务器出示的类型后重新连接
+	retryMismatch bool
+}
+
+// hostKeyAlgorithms 返回连接hostname时协商的主机密钥算法
+func (h *hostKeyChecker) hostKeyAlgorithms(hostname string) []string {
+	if h.algorithms == nil {
+		return nil
+	}
+	return h.algorithms(hostname)
+}
+
+// excludeMismatch 连接因主机密钥不一致失败时，返回排除了服务器出示的密钥类型的算法，不需要重试时返回false
+func (h *hostKeyChecker) excludeMismatch(algorithms []string, err error) ([]string, bool) {
+	var mismatch *hostKeyMismatchError
+	if !h.retryMismatch || !errors.As(err, &mismatch) {
+		return nil, false
+	}
+	keyType := mismatch.key.Type()
+	remaining := slices.DeleteFunc(slices.Clone(algorithms), func(algo string) bool {
+		return algorithmKeyType(algo) == keyType
+	})
+	if len(remaining) == 0 || len(remaining) == len(algorithms) {
+		return nil, false
+	}
+	return remaining, true
+}
+
+// newHostKeyChecker 根据配置创建主机密钥校验，配置了HostKeyAlias时使用别名（不带端口）校验
+func newHostKeyChecker(opts HostKeyOptions) (*hostKeyChecker, error) {
+	checker, err := hostKeyCheckerFor(opts)
+	if err != nil || opts.HostKeyAlias == "" {
+		return checker, err
+	}
+	alias := net.JoinHostPort(opts.HostKeyAlias, "22")
+	callback, algorithms := checker.callback, checker.algorithms
+	checker.callback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return callback(alias, remote, key)
+	}
+	if algorithms != nil {
+		checker.algorithms = func(hostname string) []string {
+			return algorithms(alias)
+		}
+	}
+	return checker, nil
+}
+
+// hostKeyCheckerFor 根据校验模式创建主机密钥校验
+func hostKeyCheckerFor(opts HostKeyOptions) (*hostKeyChecker, error) {
+	switch opts.Mode {
+	case "", HostKeyCheckingOff:
+		return &hostKeyChecker{callback: ssh.InsecureIgnoreHostKey()}, nil
+
+	case HostKeyCheckingStrict, HostKeyCheckingTOFU:
+		if opts.KnownHostsFile == "" {
+			return nil, fmt.Errorf("known_hosts file is required for host key checking mode %q", opts.Mode)
+		}
+		trustNew := opts.Mode == HostKeyCheckingTOFU
+		f, err := openKnownHosts(opts.KnownHostsFile, trustNew)
+		if err != nil {
+			return nil, err
+		}
+		return &hostKeyChecker{
+			callback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				return f.check(hostname, remote, key, trustNew)
+			},
+			algorithms: f.keyAlgorithms,
+		}, nil
+
+	case HostKeyCheckingFingerprint:
+		if len(opts.Fingerprints) == 0 {
+			return nil, fmt.Errorf("no host key fingerprints configured")
+		}
+		fingerprints := opts.Fingerprints
+		// 从全部算法开始，逐个排除与指纹不一致的密钥类型；包括旧服务器使用的ssh-rsa
+		algorithms := append(ssh.SupportedAlgorithms().HostKeys, ssh.KeyAlgoRSA)
+		return &hostKeyChecker{
+			callback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				for _, fp := range fingerprints {
+					if fingerprintMatches(fp, key) {
+						return nil
+					}
+				}
+				return &hostKeyMismatchError{hostname: hostname, key: key}
+			},
+			algorithms: func(hostname string) []string {
+				return algorithms
+			},
+			retryMismatch: true,
+		}, nil
+
+	case HostKeyCheckingCA:
+		if opts.CAFile == "" {
+			return nil, fmt.Errorf("host CA file is required for host key checking mode %q", opts.Mode)
+		}
+		callback, err := newHostCACallback(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		return &hostKeyChecker{callback: callback}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown host key checking mode %q", opts.Mode)
+	}
+}
+
+// fingerprintMatches 比较指纹，支持 SHA256:xxx 和（可带MD5:前缀的）旧式MD5格式
+func fingerprintMatches(fingerprint string, key ssh.PublicKey) bool {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if strings.HasPrefix(fingerprint, "SHA256:") {
+		return fingerprint == ssh.FingerprintSHA256(key)
+	}
+	fingerprint = strings.TrimPrefix(fingerprint, "MD5:")
+	return strings.EqualFold(fingerprint, ssh.FingerprintLegacyMD5(key))
+}
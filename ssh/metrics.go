@@ -0,0 +1,49 @@
+package ssh
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics SSH客户端自身的指标
+type Metrics struct {
+	handshakeDuration *prometheus.HistogramVec
+}
+
+// NewMetrics 创建SSH客户端指标，prefix为指标名称前缀
+func NewMetrics(prefix string) *Metrics {
+	return &Metrics{
+		handshakeDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    prefix + "ssh_handshake_duration_seconds",
+				Help:    "Duration of establishing an authenticated SSH connection (dial, handshake and authentication)",
+				Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"host"},
+		),
+	}
+}
+
+// observeHandshake 记录一次连接建立耗时
+func (m *Metrics) observeHandshake(host string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.handshakeDuration.WithLabelValues(host).Observe(d.Seconds())
+}
+
+// Forget 删除主机的连接指标（例如不再探测的 /probe 目标）
+func (m *Metrics) Forget(host string) {
+	m.handshakeDuration.DeleteLabelValues(host)
+}
+
+// Describe 实现Prometheus Collector接口
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.handshakeDuration.Describe(ch)
+}
+
+// Collect 实现Prometheus Collector接口
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.handshakeDuration.Collect(ch)
+}
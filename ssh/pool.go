@@ -0,0 +1,161 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 连接池默认参数
+const (
+	DefaultKeepaliveInterval = 30 * time.Second
+	DefaultIdleTimeout       = 5 * time.Minute
+)
+
+// PoolOptions 连接池参数
+type PoolOptions struct {
+	KeepaliveInterval time.Duration // keepalive检查间隔
+	IdleTimeout       time.Duration // 连接空闲超过该时间后关闭
+	Metrics           *Metrics      // 连接指标（可选）
+}
+
+// Pool 连接管理器，为每个主机保持一个已认证的SSH连接
+// 跳板机同样作为连接池中的客户端，经过同一跳板机的所有主机共享其连接
+type Pool struct {
+	opts PoolOptions
+
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+	done    chan struct{}
+}
+
+// pooledClient 连接池中的客户端
+type pooledClient struct {
+	client   *Client
+	lastUsed time.Time
+}
+
+// NewPool 创建连接池并启动后台keepalive检查
+func NewPool(opts PoolOptions) *Pool {
+	if opts.KeepaliveInterval <= 0 {
+		opts.KeepaliveInterval = DefaultKeepaliveInterval
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+
+	p := &Pool{
+		opts:    opts,
+		clients: make(map[string]*pooledClient),
+		done:    make(chan struct{}),
+	}
+	go p.maintain()
+	return p
+}
+
+// poolKey 连接池中区分客户端的键，认证参数不同的同一主机使用不同连接
+func poolKey(opts Options) string {
+	return fmt.Sprintf("%+v", opts)
+}
+
+// Get 获取主机的已连接客户端，连接不存在或已断开时自动建立，ctx结束时不再等待连接
+// 返回的客户端由连接池管理，调用方不应关闭
+func (p *Pool) Get(ctx context.Context, opts Options) (*Client, error) {
+	p.mu.Lock()
+	client, err := p.clientLocked(opts, time.Now())
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := client.connection(ctx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// clientLocked 获取或创建主机及其跳板机的客户端并更新使用时间，调用方需持有p.mu
+// 跳板机的使用时间不早于经过它的主机，因此不会先于这些主机被当作空闲连接关闭
+func (p *Pool) clientLocked(opts Options, now time.Time) (*Client, error) {
+	var jump *Client
+	if len(opts.JumpHosts) > 0 {
+		jumpOpts := jumpOptions(opts.JumpHosts)
+		var err error
+		jump, err = p.clientLocked(jumpOpts, now)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", jumpOpts.Host, err)
+		}
+	}
+
+	key := poolKey(opts)
+	pc, ok := p.clients[key]
+	if !ok {
+		client, err := newClient(opts)
+		if err != nil {
+			return nil, err
+		}
+		client.metrics = p.opts.Metrics
+		client.jump = jump
+		pc = &pooledClient{client: client}
+		p.clients[key] = pc
+	}
+	pc.lastUsed = now
+	return pc.client, nil
+}
+
+// maintain 定期发送keepalive并关闭空闲连接
+func (p *Pool) maintain() {
+	ticker := time.NewTicker(p.opts.KeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		// 只在p.mu下取出客户端，客户端的锁在建立连接期间一直持有，
+		// 不能在持有p.mu时获取，否则一台无响应的主机会阻塞所有Get
+		now := time.Now()
+		var idle, active []*Client
+
+		p.mu.Lock()
+		for key, pc := range p.clients {
+			if now.Sub(pc.lastUsed) > p.opts.IdleTimeout {
+				idle = append(idle, pc.client)
+				delete(p.clients, key)
+				continue
+			}
+			active = append(active, pc.client)
+		}
+		p.mu.Unlock()
+
+		for _, client := range idle {
+			logger.Printf("Closing idle connection to %s:%d", client.host, client.port)
+			client.Close()
+		}
+		for _, client := range active {
+			if !client.Connected() {
+				continue
+			}
+			if err := client.Keepalive(p.opts.KeepaliveInterval); err != nil {
+				logger.Printf("Keepalive to %s:%d failed, will reconnect on next use: %v", client.host, client.port, err)
+			}
+		}
+	}
+}
+
+// Close 关闭连接池中的所有连接
+func (p *Pool) Close() {
+	close(p.done)
+
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = make(map[string]*pooledClient)
+	p.mu.Unlock()
+	for _, pc := range clients {
+		pc.client.Close()
+	}
+}
@@ -0,0 +1,344 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// proxyGreeting 代理在握手完成后立即发送的数据，用来检查握手没有多读属于隧道的数据
+const proxyGreeting = "SSH-2.0-test\r\n"
+
+// serveProxy 启动测试代理，每个连接交给handle处理，返回监听地址
+func serveProxy(t *testing.T, handle func(conn net.Conn) error) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				// 代理一侧的错误表现为客户端握手失败，这里不再单独检查
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// readGreeting 读取隧道中代理发送的第一行数据
+func readGreeting(t *testing.T, conn net.Conn) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, len(proxyGreeting))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("failed to read from tunnel: %v", err)
+	}
+	if string(buf) != proxyGreeting {
+		t.Errorf("tunnel data = %q, want %q", buf, proxyGreeting)
+	}
+}
+
+// socks5TestServer 测试用SOCKS5代理
+type socks5TestServer struct {
+	user, password string // 不为空时要求用户名/密码认证（RFC 1929）
+	reply          byte   // connect应答码
+	boundAddr      []byte // 应答中的绑定地址（地址类型及地址）
+	targets        chan string
+}
+
+// handle 处理一个SOCKS5连接，将请求的目标地址发送到targets
+func (s *socks5TestServer) handle(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return err
+	}
+	want := byte(socks5AuthNone)
+	if s.user != "" {
+		want = socks5AuthPassword
+	}
+	if !strings.ContainsRune(string(methods), rune(want)) {
+		_, err := conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+		return err
+	}
+	if _, err := conn.Write([]byte{socks5Version, want}); err != nil {
+		return err
+	}
+
+	if want == socks5AuthPassword {
+		user, password, err := readSOCKS5Credentials(r)
+		if err != nil {
+			return err
+		}
+		status := byte(0x00)
+		if user != s.user || password != s.password {
+			status = 0x01
+		}
+		if _, err := conn.Write([]byte{0x01, status}); err != nil || status != 0x00 {
+			return err
+		}
+	}
+
+	target, err := readSOCKS5Request(r)
+	if err != nil {
+		return err
+	}
+	s.targets <- target
+
+	resp := []byte{socks5Version, s.reply, 0x00}
+	resp = append(resp, s.boundAddr...)
+	resp = binary.BigEndian.AppendUint16(resp, 1080)
+	if s.reply == 0x00 {
+		resp = append(resp, proxyGreeting...)
+	}
+	_, err = conn.Write(resp)
+	return err
+}
+
+// readSOCKS5Credentials 读取RFC 1929用户名/密码认证请求
+func readSOCKS5Credentials(r io.Reader) (string, string, error) {
+	var fields [2]string
+	version := make([]byte, 1)
+	if _, err := io.ReadFull(r, version); err != nil {
+		return "", "", err
+	}
+	for i := range fields {
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(r, n); err != nil {
+			return "", "", err
+		}
+		field := make([]byte, n[0])
+		if _, err := io.ReadFull(r, field); err != nil {
+			return "", "", err
+		}
+		fields[i] = string(field)
+	}
+	return fields[0], fields[1], nil
+}
+
+// readSOCKS5Request 读取connect请求，返回目标地址 host:port
+func readSOCKS5Request(r io.Reader) (string, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	if header[1] != socks5CmdConnect {
+		return "", fmt.Errorf("unexpected command %d", header[1])
+	}
+	var host string
+	switch header[3] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if header[3] == socks5AddrIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case socks5AddrDomain:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(r, n); err != nil {
+			return "", err
+		}
+		domain := make([]byte, n[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("unknown address type %d", header[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+func TestSOCKS5Dial(t *testing.T) {
+	boundIPv4 := append([]byte{socks5AddrIPv4}, net.IPv4(10, 0, 0, 1).To4()...)
+	boundIPv6 := append([]byte{socks5AddrIPv6}, net.ParseIP("2001:db8::1")...)
+	boundDomain := append([]byte{socks5AddrDomain, byte(len("proxy.example"))}, "proxy.example"...)
+
+	tests := []struct {
+		name      string
+		server    socks5TestServer
+		userinfo  string
+		target    string
+		wantError string
+	}{
+		{
+			name:   "no auth, domain bound address",
+			server: socks5TestServer{boundAddr: boundDomain},
+			target: "host.example:22",
+		},
+		{
+			name:     "password auth, IPv4 bound address",
+			server:   socks5TestServer{user: "user", password: "pass", boundAddr: boundIPv4},
+			userinfo: "user:pass@",
+			target:   "192.0.2.10:2222",
+		},
+		{
+			name:     "password auth, IPv6 bound address",
+			server:   socks5TestServer{user: "user", password: "pass", boundAddr: boundIPv6},
+			userinfo: "user:pass@",
+			target:   "[2001:db8::10]:22",
+		},
+		{
+			name:      "wrong password",
+			server:    socks5TestServer{user: "user", password: "pass", boundAddr: boundIPv4},
+			userinfo:  "user:wrong@",
+			target:    "192.0.2.10:22",
+			wantError: "SOCKS5 authentication failed",
+		},
+		{
+			name:      "auth required",
+			server:    socks5TestServer{user: "user", password: "pass", boundAddr: boundIPv4},
+			target:    "192.0.2.10:22",
+			wantError: "rejected authentication method",
+		},
+		{
+			name:      "connection refused",
+			server:    socks5TestServer{reply: 0x05, boundAddr: boundIPv4},
+			target:    "192.0.2.10:22",
+			wantError: "connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.server
+			server.targets = make(chan string, 1)
+			addr := serveProxy(t, server.handle)
+
+			d, err := newProxyDialer("socks5://" + tt.userinfo + addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, err := d.dial(ctx, tt.target)
+			if tt.wantError != "" {
+				if err == nil {
+					conn.Close()
+					t.Fatalf("dial() succeeded, want error %q", tt.wantError)
+				}
+				if !errors.Is(err, ErrProxy) || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("dial() error = %v, want proxy error %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			if got := <-server.targets; got != tt.target {
+				t.Errorf("proxy received target %q, want %q", got, tt.target)
+			}
+			readGreeting(t, conn)
+		})
+	}
+}
+
+// httpProxyHandler 测试用HTTP代理，认证为user:pass时返回status和额外的响应头，否则返回407
+func httpProxyHandler(targets chan<- string, status int, extraHeader string) func(conn net.Conn) error {
+	return func(conn net.Conn) error {
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return err
+		}
+		if req.Method != http.MethodConnect {
+			return fmt.Errorf("unexpected method %s", req.Method)
+		}
+		targets <- req.Host
+
+		code := status
+		if req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")) {
+			code = http.StatusProxyAuthRequired
+		}
+		resp := fmt.Sprintf("HTTP/1.1 %d %s\r\n%s\r\n", code, http.StatusText(code), extraHeader)
+		if code == http.StatusOK {
+			// 隧道数据与响应头一起发送
+			resp += proxyGreeting
+		}
+		_, err = io.WriteString(conn, resp)
+		return err
+	}
+}
+
+func TestHTTPConnectDial(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		extraHeader string
+		userinfo    string
+		wantError   string
+	}{
+		{name: "200", status: http.StatusOK, extraHeader: "Proxy-Agent: test\r\n", userinfo: "user:pass@"},
+		{name: "407", status: http.StatusOK, userinfo: "user:wrong@", wantError: "407 Proxy Authentication Required"},
+		{
+			name:        "header too long",
+			status:      http.StatusOK,
+			extraHeader: "X-Padding: " + strings.Repeat("a", 9000) + "\r\n",
+			userinfo:    "user:pass@",
+			wantError:   "CONNECT response header too long",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets := make(chan string, 1)
+			addr := serveProxy(t, httpProxyHandler(targets, tt.status, tt.extraHeader))
+
+			d, err := newProxyDialer("http://" + tt.userinfo + addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, err := d.dial(ctx, "host.example:22")
+			if tt.wantError != "" {
+				if err == nil {
+					conn.Close()
+					t.Fatalf("dial() succeeded, want error %q", tt.wantError)
+				}
+				if !errors.Is(err, ErrProxy) || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("dial() error = %v, want proxy error %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			if got := <-targets; got != "host.example:22" {
+				t.Errorf("proxy received target %q, want %q", got, "host.example:22")
+			}
+			readGreeting(t, conn)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// DockerExecutor 通过 docker exec 在容器内执行命令，适用于没有sshd的容器
+type DockerExecutor struct {
+	docker    string // docker命令路径
+	container string // 容器名称或ID
+}
+
+// NewDockerExecutor 创建docker执行器，docker为空时使用PATH中的docker
+func NewDockerExecutor(docker, container string) *DockerExecutor {
+	if docker == "" {
+		docker = "docker"
+	}
+	return &DockerExecutor{docker: docker, container: container}
+}
+
+// ExecuteCommand 执行命令
+// docker exec 本身的错误（例如容器不存在）通过退出码和stderr返回
+func (e *DockerExecutor) ExecuteCommand(ctx context.Context, command string) (Result, error) {
+	result, err := run(ctx, exec.CommandContext(ctx, e.docker, "exec", e.container, "sh", "-c", command))
+	if err != nil {
+		return result, fmt.Errorf("docker exec in %s: %w", e.container, err)
+	}
+	return result, nil
+}
+
+// NsenterExecutor 通过 nsenter 进入目标进程的mount、UTS、IPC、网络和PID命名空间执行命令
+// 采集器需要有相应权限（通常为root）
+type NsenterExecutor struct {
+	pid int // 目标进程PID
+}
+
+// NewNsenterExecutor 创建nsenter执行器
+func NewNsenterExecutor(pid int) *NsenterExecutor {
+	return &NsenterExecutor{pid: pid}
+}
+
+// ExecuteCommand 执行命令
+func (e *NsenterExecutor) ExecuteCommand(ctx context.Context, command string) (Result, error) {
+	args := []string{"-t", strconv.Itoa(e.pid), "-m", "-u", "-i", "-n", "-p", "--", "sh", "-c", command}
+	result, err := run(ctx, exec.CommandContext(ctx, "nsenter", args...))
+	if err != nil {
+		return result, fmt.Errorf("nsenter into pid %d: %w", e.pid, err)
+	}
+	return result, nil
+}
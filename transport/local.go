@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killWaitDelay 命令被终止后等待其输出管道关闭的最长时间
+const killWaitDelay = time.Second
+
+// LocalExecutor 在采集器所在机器上通过 sh -c 执行命令
+type LocalExecutor struct{}
+
+// NewLocalExecutor 创建本地执行器
+func NewLocalExecutor() *LocalExecutor {
+	return &LocalExecutor{}
+}
+
+// ExecuteCommand 执行命令
+func (e *LocalExecutor) ExecuteCommand(ctx context.Context, command string) (Result, error) {
+	return run(ctx, exec.CommandContext(ctx, "sh", "-c", command))
+}
+
+// run 执行命令并收集输出和退出状态，ctx结束导致的失败返回包装ctx.Err()的错误
+func run(ctx context.Context, cmd *exec.Cmd) (Result, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = killWaitDelay
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+
+	if err != nil && ctx.Err() != nil {
+		if errors.Is(err, ctx.Err()) {
+			return result, err
+		}
+		return result, fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Signal = signalName(status.Signal())
+		}
+		return result, nil
+	}
+	return result, err
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package transport
+
+import (
+	"strings"
+	"syscall"
+)
+
+// signalName 返回信号名称，非Unix系统上没有信号名称表，使用信号的描述
+func signalName(sig syscall.Signal) string {
+	return strings.ToUpper(sig.String())
+}
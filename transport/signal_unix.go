@@ -0,0 +1,18 @@
+//go:build unix
+
+package transport
+
+import (
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// signalName 返回与SSH exit-signal一致的信号名称（不带SIG前缀，例如 KILL、TERM、SEGV）
+func signalName(sig syscall.Signal) string {
+	if name := unix.SignalName(sig); name != "" {
+		return strings.TrimPrefix(name, "SIG")
+	}
+	return strings.ToUpper(sig.String())
+}
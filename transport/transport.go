@@ -0,0 +1,30 @@
+package transport
+
+import (
+	"context"
+	"time"
+)
+
+// 主机的命令执行方式
+const (
+	SSH     = "ssh"     // 通过SSH在远程主机上执行（默认）
+	Local   = "local"   // 在采集器所在机器上执行
+	Docker  = "docker"  // 通过 docker exec 在容器内执行
+	Nsenter = "nsenter" // 通过 nsenter 进入目标进程的命名空间执行
+)
+
+// Result 命令执行结果
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int           // 退出码，被信号终止时为-1
+	Signal   string        // 终止命令的信号名称，正常退出时为空
+	Duration time.Duration // 执行耗时
+}
+
+// Executor 在目标主机上执行命令
+// 命令能够执行时error为nil，退出码和输出通过Result返回；
+// ctx被取消或超时后命令会被终止，返回的错误包装ctx.Err()
+type Executor interface {
+	ExecuteCommand(ctx context.Context, command string) (Result, error)
+}